@@ -0,0 +1,272 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrAdvisoryLockTaken is returned by the TryAdvisoryLock methods when the lock is held by another session
+// or transaction
+var ErrAdvisoryLockTaken = errors.New("advisory lock is taken")
+
+// advisoryUnlockTimeout bounds releasing a session lock, the connection is discarded when it fails
+const advisoryUnlockTimeout = 10 * time.Second
+
+// AdvisoryKey identifies an advisory lock
+type AdvisoryKey int64
+
+// AdvisoryKeyFor returns the key of a lock identified by name, the name is hashed with 64 bit FNV-1a
+func AdvisoryKeyFor(name string) AdvisoryKey {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+
+	return AdvisoryKey(h.Sum64())
+}
+
+// advisoryFunc returns the name of the advisory lock function, e.g. pg_try_advisory_xact_lock_shared
+func advisoryFunc(try, xact, shared bool) string {
+	var b strings.Builder
+	b.WriteString("pg_")
+	if try {
+		b.WriteString("try_")
+	}
+	b.WriteString("advisory_")
+	if xact {
+		b.WriteString("xact_")
+	}
+	b.WriteString("lock")
+	if shared {
+		b.WriteString("_shared")
+	}
+
+	return b.String()
+}
+
+// AdvisoryLock is a session level advisory lock held on a connection taken from the client's pool until
+// it is unlocked. Shutdown waits for held locks and closes their connections when it gives up.
+type AdvisoryLock struct {
+	Key    AdvisoryKey
+	Shared bool
+
+	client   *Client
+	conn     *sql.Conn
+	mu       sync.Mutex
+	released chan struct{}
+}
+
+// AdvisoryLock waits for the exclusive session level lock of key. The lock is released by Unlock or when
+// ctx is done.
+func (c *Client) AdvisoryLock(ctx context.Context, key AdvisoryKey) (*AdvisoryLock, error) {
+	return c.advisoryLock(ctx, key, false, false)
+}
+
+// AdvisoryLockShared waits for the shared session level lock of key, see AdvisoryLock
+func (c *Client) AdvisoryLockShared(ctx context.Context, key AdvisoryKey) (*AdvisoryLock, error) {
+	return c.advisoryLock(ctx, key, false, true)
+}
+
+// TryAdvisoryLock takes the exclusive session level lock of key or returns ErrAdvisoryLockTaken, see
+// AdvisoryLock
+func (c *Client) TryAdvisoryLock(ctx context.Context, key AdvisoryKey) (*AdvisoryLock, error) {
+	return c.advisoryLock(ctx, key, true, false)
+}
+
+// TryAdvisoryLockShared takes the shared session level lock of key or returns ErrAdvisoryLockTaken, see
+// AdvisoryLock
+func (c *Client) TryAdvisoryLockShared(ctx context.Context, key AdvisoryKey) (*AdvisoryLock, error) {
+	return c.advisoryLock(ctx, key, true, true)
+}
+
+// WithAdvisoryLock runs f while holding the exclusive session level lock of key, the lock is released
+// when f returns or panics
+func (c *Client) WithAdvisoryLock(ctx context.Context, key AdvisoryKey, f func(context.Context) error) (err error) {
+	l, err := c.AdvisoryLock(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if unlockErr := l.Unlock(); err == nil {
+			err = unlockErr
+		}
+	}()
+
+	return f(ctx)
+}
+
+func (c *Client) advisoryLock(ctx context.Context, key AdvisoryKey, try, shared bool) (*AdvisoryLock, error) {
+	if c.DB == nil {
+		return nil, errors.New("db is nil")
+	}
+
+	if err := c.work.add(workLock); err != nil {
+		return nil, err
+	}
+
+	conn, err := c.DB.Conn(ctx)
+	if err != nil {
+		c.work.done(workLock)
+		return nil, err
+	}
+
+	q := "SELECT " + advisoryFunc(try, false, shared) + "($1)"
+	acquired := true
+	err = c.observe(ctx, newQueryEvent(ctx, q, []interface{}{key}), func(ctx context.Context) error {
+		if try {
+			return conn.QueryRowContext(ctx, q, key).Scan(&acquired)
+		}
+
+		_, err := conn.ExecContext(ctx, q, key)
+		return err
+	})
+
+	if err != nil {
+		// the server may hold the lock already when ctx ended the query, the session must not be reused
+		discardConn(conn)
+		c.work.done(workLock)
+		return nil, err
+	}
+
+	if !acquired {
+		_ = conn.Close()
+		c.work.done(workLock)
+		return nil, ErrAdvisoryLockTaken
+	}
+
+	l := &AdvisoryLock{Key: key, Shared: shared, client: c, conn: conn, released: make(chan struct{})}
+
+	abandoned := c.work.context().Done()
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = l.Unlock()
+		case <-abandoned:
+			l.discard()
+		case <-l.released:
+		}
+	}()
+
+	return l, nil
+}
+
+// Unlock releases the lock and returns its connection to the pool, unlocking again does nothing. When
+// releasing fails the connection is closed which releases the lock too.
+func (l *AdvisoryLock) Unlock() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	select {
+	case <-l.released:
+		return nil
+	default:
+	}
+	close(l.released)
+	defer l.client.work.done(workLock)
+
+	ctx, cancel := context.WithTimeout(context.Background(), advisoryUnlockTimeout)
+	defer cancel()
+
+	fn := "pg_advisory_unlock"
+	if l.Shared {
+		fn += "_shared"
+	}
+
+	q := "SELECT " + fn + "($1)"
+	var unlocked bool
+	err := l.client.observe(ctx, newQueryEvent(ctx, q, []interface{}{l.Key}), func(ctx context.Context) error {
+		return l.conn.QueryRowContext(ctx, q, l.Key).Scan(&unlocked)
+	})
+
+	if err == nil && !unlocked {
+		err = errors.New("advisory lock was not held")
+	}
+
+	if err != nil {
+		discardConn(l.conn)
+		return err
+	}
+
+	return l.conn.Close()
+}
+
+// discard releases the lock by closing its connection without unlocking, when Shutdown abandons it
+func (l *AdvisoryLock) discard() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	select {
+	case <-l.released:
+		return
+	default:
+	}
+	close(l.released)
+
+	discardConn(l.conn)
+	l.client.work.done(workLock)
+}
+
+// discardConn closes conn without returning it to the pool so its session and its locks end
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(interface{}) error {
+		return driver.ErrBadConn
+	})
+	_ = conn.Close()
+}
+
+// AdvisoryLock waits for the exclusive transaction level lock of key, it is released when the transaction
+// ends
+func (tx *Tx) AdvisoryLock(ctx context.Context, key AdvisoryKey) error {
+	return tx.advisoryLock(ctx, key, false, false)
+}
+
+// AdvisoryLockShared waits for the shared transaction level lock of key, see AdvisoryLock
+func (tx *Tx) AdvisoryLockShared(ctx context.Context, key AdvisoryKey) error {
+	return tx.advisoryLock(ctx, key, false, true)
+}
+
+// TryAdvisoryLock takes the exclusive transaction level lock of key or returns ErrAdvisoryLockTaken
+func (tx *Tx) TryAdvisoryLock(ctx context.Context, key AdvisoryKey) error {
+	return tx.advisoryLock(ctx, key, true, false)
+}
+
+// TryAdvisoryLockShared takes the shared transaction level lock of key or returns ErrAdvisoryLockTaken
+func (tx *Tx) TryAdvisoryLockShared(ctx context.Context, key AdvisoryKey) error {
+	return tx.advisoryLock(ctx, key, true, true)
+}
+
+func (tx *Tx) advisoryLock(ctx context.Context, key AdvisoryKey, try, shared bool) error {
+	q := "SELECT " + advisoryFunc(try, true, shared) + "($1)"
+	if !try {
+		_, err := tx.ExecContext(ctx, q, key)
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, q, key)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var acquired bool
+	if rows.Next() {
+		if err := rows.Scan(&acquired); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if !acquired {
+		return ErrAdvisoryLockTaken
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package psql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvisoryKeyFor(t *testing.T) {
+	require.Equal(t, AdvisoryKeyFor("nightly-report"), AdvisoryKeyFor("nightly-report"))
+	require.NotEqual(t, AdvisoryKeyFor("nightly-report"), AdvisoryKeyFor("hourly-report"))
+	require.Equal(t, AdvisoryKey(-3750763034362895579), AdvisoryKeyFor(""))
+}
+
+func TestAdvisoryFunc(t *testing.T) {
+	require.Equal(t, "pg_advisory_lock", advisoryFunc(false, false, false))
+	require.Equal(t, "pg_try_advisory_lock_shared", advisoryFunc(true, false, true))
+	require.Equal(t, "pg_advisory_xact_lock", advisoryFunc(false, true, false))
+	require.Equal(t, "pg_try_advisory_xact_lock_shared", advisoryFunc(true, true, true))
+}
+
+func TestClient_AdvisoryLock(t *testing.T) {
+	c := NewClient(nil)
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	key := AdvisoryKeyFor("TestClient_AdvisoryLock")
+
+	l, err := c.AdvisoryLock(ctx, key)
+	require.Nil(t, err)
+
+	_, err = c.TryAdvisoryLock(ctx, key)
+	require.Equal(t, ErrAdvisoryLockTaken, err)
+
+	_, err = c.TryAdvisoryLockShared(ctx, key)
+	require.Equal(t, ErrAdvisoryLockTaken, err)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = c.AdvisoryLock(timeoutCtx, key)
+	require.NotNil(t, err)
+
+	require.Nil(t, l.Unlock())
+	require.Nil(t, l.Unlock())
+
+	// shared locks only exclude exclusive ones
+	s1, err := c.AdvisoryLockShared(ctx, key)
+	require.Nil(t, err)
+	s2, err := c.TryAdvisoryLockShared(ctx, key)
+	require.Nil(t, err)
+
+	_, err = c.TryAdvisoryLock(ctx, key)
+	require.Equal(t, ErrAdvisoryLockTaken, err)
+	require.Nil(t, s1.Unlock())
+	require.Nil(t, s2.Unlock())
+
+	// locks are released when their context is done
+	lockCtx, cancelLock := context.WithCancel(ctx)
+	_, err = c.AdvisoryLock(lockCtx, key)
+	require.Nil(t, err)
+	cancelLock()
+
+	require.Eventually(t, func() bool {
+		l, err := c.TryAdvisoryLock(ctx, key)
+		if err != nil {
+			return false
+		}
+		return l.Unlock() == nil
+	}, time.Second, 10*time.Millisecond)
+
+	err = c.WithAdvisoryLock(ctx, key, func(ctx context.Context) error {
+		_, err := c.TryAdvisoryLock(ctx, key)
+		return err
+	})
+	require.Equal(t, ErrAdvisoryLockTaken, err)
+
+	l, err = c.TryAdvisoryLock(ctx, key)
+	require.Nil(t, err)
+	require.Nil(t, l.Unlock())
+
+	// Shutdown waits for held locks and releases them when it gives up
+	other := NewClient(nil)
+	if err := other.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+
+	_, err = other.AdvisoryLock(ctx, key)
+	require.Nil(t, err)
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancelShutdown()
+	require.Equal(t, &ShutdownError{Locks: 1, Err: context.DeadlineExceeded}, other.Shutdown(shutdownCtx))
+
+	_, err = other.AdvisoryLock(ctx, key)
+	require.Equal(t, ErrClientShutdown, err)
+
+	require.Eventually(t, func() bool {
+		l, err := c.TryAdvisoryLock(ctx, key)
+		if err != nil {
+			return false
+		}
+		return l.Unlock() == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestTx_AdvisoryLock(t *testing.T) {
+	c := NewClient(nil)
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	key := AdvisoryKeyFor("TestTx_AdvisoryLock")
+
+	err := c.RunInTransaction(ctx, func(ctx context.Context, tx *Tx) error {
+		require.Nil(t, tx.AdvisoryLock(ctx, key))
+
+		_, err := c.TryAdvisoryLock(WithoutTx(ctx), key)
+		require.Equal(t, ErrAdvisoryLockTaken, err)
+
+		return c.RunInTransaction(WithoutTx(ctx), func(ctx context.Context, other *Tx) error {
+			require.Equal(t, ErrAdvisoryLockTaken, other.TryAdvisoryLockShared(ctx, key))
+			return nil
+		}, nil)
+	}, nil)
+	require.Nil(t, err)
+
+	// released when the transaction ended
+	err = c.RunInTransaction(ctx, func(ctx context.Context, tx *Tx) error {
+		require.Nil(t, tx.TryAdvisoryLock(ctx, key))
+		return tx.AdvisoryLockShared(ctx, key)
+	}, nil)
+	require.Nil(t, err)
+}
@@ -0,0 +1,64 @@
+package psql
+
+import (
+	"context"
+)
+
+type txKey struct{}
+
+// ContextWithTx returns a context carrying tx as the ambient transaction. Statements run through the
+// client that began tx with this context, or a context derived from it, run in tx. RunInTransaction and
+// RunInSavepoint pass such a context to their callback.
+func ContextWithTx(ctx context.Context, tx *Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext returns the ambient transaction of ctx
+func TxFromContext(ctx context.Context) (*Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*Tx)
+	return tx, ok && tx != nil
+}
+
+// WithoutTx returns a context without an ambient transaction so statements run outside of it
+func WithoutTx(ctx context.Context) context.Context {
+	return context.WithValue(ctx, txKey{}, (*Tx)(nil))
+}
+
+// Resolve returns the ambient transaction of ctx if it was begun by c and has not ended, c otherwise.
+//
+// Statements run through the client, its queries and the package level helpers resolve the ambient
+// transaction themselves so repositories can take only a context:
+//
+//	func (r *Repo) Find(ctx context.Context, id int64) (*User, error) {
+//		u := &User{}
+//		return u, r.client.Select("users").Where(psql.Attrs{"id": id}).Scan(ctx, u)
+//	}
+func (c *Client) Resolve(ctx context.Context) QueryClient {
+	if tx, ok := c.ambientTx(ctx); ok {
+		return tx
+	}
+
+	return c
+}
+
+func (c *Client) ambientTx(ctx context.Context) (*Tx, bool) {
+	tx, ok := TxFromContext(ctx)
+	if !ok || tx.client != c {
+		return nil, false
+	}
+
+	if outcome, _ := tx.state(); outcome != "" {
+		return nil, false
+	}
+
+	return tx, true
+}
+
+// resolveClient returns the ambient transaction of ctx when c is the *Client that began it
+func resolveClient(ctx context.Context, c QueryClient) QueryClient {
+	if client, ok := c.(*Client); ok {
+		return client.Resolve(ctx)
+	}
+
+	return c
+}
@@ -0,0 +1,95 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Resolve(t *testing.T) {
+	c := NewClient(nil)
+	other := NewClient(nil)
+	ctx := context.Background()
+
+	_, ok := TxFromContext(ctx)
+	require.False(t, ok)
+	require.Equal(t, c, c.Resolve(ctx))
+
+	tx := &Tx{client: c}
+	txCtx := ContextWithTx(ctx, tx)
+
+	found, ok := TxFromContext(txCtx)
+	require.True(t, ok)
+	require.Equal(t, tx, found)
+
+	require.Equal(t, tx, c.Resolve(txCtx))
+	require.Equal(t, tx, resolveClient(txCtx, c))
+
+	// transactions of other clients are ignored
+	require.Equal(t, other, other.Resolve(txCtx))
+
+	_, ok = TxFromContext(WithoutTx(txCtx))
+	require.False(t, ok)
+	require.Equal(t, c, c.Resolve(WithoutTx(txCtx)))
+
+	// ended transactions are ignored
+	tx.outcome = TxCommitted
+	require.Equal(t, c, c.Resolve(txCtx))
+}
+
+func TestClient_AmbientTx(t *testing.T) {
+	c := NewClient(nil)
+
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+
+	if _, err := c.Exec(modelsTable); err != nil {
+		t.Fatalf("failed to create table %v", err)
+	}
+
+	defer func() {
+		_, _ = c.Exec("drop table mock_models")
+		_ = c.Close()
+	}()
+
+	count := func(ctx context.Context) int {
+		var n int
+		err := c.Select("mock_models", "count(*)").Scan(ctx, &n)
+		require.Nil(t, err)
+		return n
+	}
+
+	ctx := context.Background()
+	someErr := errors.New("some error")
+
+	err := c.RunInTransaction(ctx, func(ctx context.Context, tx *Tx) error {
+		// the client and the package level helpers run in tx
+		require.Nil(t, c.Insert(ctx, &MockModel{IntField: 1}))
+		require.Nil(t, Insert(ctx, c, &MockModel{IntField: 2}))
+
+		_, err := c.RawQuery(ctx, "select 1")
+		require.Nil(t, err)
+
+		require.Equal(t, 2, count(ctx))
+		require.Equal(t, 0, count(WithoutTx(ctx)))
+
+		var n int
+		require.Nil(t, c.QueryRowContext(ctx, "SELECT count(*) FROM mock_models").Scan(&n))
+		require.Equal(t, 2, n)
+
+		// nested in a savepoint
+		err = RunInTransaction(ctx, c, func(ctx context.Context, tx *Tx) error {
+			require.Nil(t, c.Insert(ctx, &MockModel{IntField: 3}))
+			return someErr
+		})
+		require.Equal(t, someErr, err)
+		require.Equal(t, 2, count(ctx))
+
+		return someErr
+	}, nil)
+	require.Equal(t, someErr, err)
+	require.Equal(t, 0, count(ctx))
+}
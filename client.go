@@ -4,8 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
-	"os"
+	"time"
 )
 
 // Client is a helper type to easily connect to PostgreSQL database instances.
@@ -13,58 +12,188 @@ import (
 // It satisfies the `health.Metric` interface.
 type Client struct {
 	*sql.DB
-	connStr string
+	connStr          string
+	target           string // connStr with the password redacted
+	statementTimeout time.Duration
+	hooks            []Hook
+	metrics          *MetricsCollector
+	redactColumns    []string
+	replicas         *replicaSet
+	pool             poolConfig
+	configErr        error // reported by Start
+	work             *work // shared with the replicas
+	healthThresholds HealthThresholds
 }
 
-func NewClient(cfg *Config) *Client {
-	connStr := os.Getenv("DATABASE_URL")
+// ClientOption configures optional behavior of a Client
+type ClientOption func(*Client)
 
-	if connStr == "" && cfg != nil {
-		connStr = cfg.connString()
+// WithStatementTimeout sets the default statement_timeout of the client's connections like
+// Config.StatementTimeout, see Query.Timeout to override it for a single query.
+func WithStatementTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.statementTimeout = d
 	}
-
-	return &Client{connStr: connStr}
 }
 
-func (c *Client) Start(driverName string) error {
-	if driverName == "" {
-		driverName = "postgres"
+// NewClient returns a client connecting with cfg, or with the config from the environment when cfg is nil,
+// see ConfigFromEnv. Invalid configs are reported by Start.
+func NewClient(cfg *Config, opts ...ClientOption) *Client {
+	c := &Client{work: &work{}, healthThresholds: DefaultHealthThresholds}
+
+	if cfg == nil {
+		cfg, c.configErr = ConfigFromEnv()
 	}
 
-	db, err := sql.Open(driverName, c.connStr)
-	if err != nil {
-		return fmt.Errorf("unable to open connection to postgres db: %w", err)
+	if cfg != nil {
+		c.configErr = cfg.Validate()
+		c.statementTimeout = cfg.StatementTimeout
+		c.pool = newPoolConfig(cfg)
+		c.redactColumns = cfg.SlowQueryDenyColumns
+
+		if cfg.SlowQueryThreshold > 0 {
+			WithSlowQueryLog(SlowQueryLog{Threshold: cfg.SlowQueryThreshold, DenyColumns: cfg.SlowQueryDenyColumns})(c)
+		}
 	}
 
-	c.DB = db
+	for _, opt := range opts {
+		opt(c)
+	}
 
-	return nil
+	if cfg != nil {
+		// the default statement timeout is set once per connection
+		conf := *cfg
+		conf.StatementTimeout = c.statementTimeout
+		c.connStr = conf.connString()
+		c.target = conf.String()
+	}
+
+	return c
+}
+
+// Start opens the connection pool without connecting to the server, see StartContext to wait for it
+func (c *Client) Start(driverName string) error {
+	return c.StartContext(context.Background(), StartOptions{DriverName: driverName})
 }
 
+// Stop closes the client without waiting for running work, see Shutdown
 func (c *Client) Stop() error {
+	if c.metrics != nil {
+		c.metrics.Stop()
+	}
+
 	return c.Close()
 }
 
+// Close closes the connections to the database and its replicas
+func (c *Client) Close() error {
+	var err error
+	if c.DB != nil {
+		err = c.DB.Close()
+	}
+
+	if c.replicas != nil {
+		if replicasErr := c.replicas.close(); err == nil {
+			err = replicasErr
+		}
+	}
+
+	return err
+}
+
 func (c *Client) Started() bool {
 	return c.DB != nil
 }
 
+// StatementTimeout returns the default statement timeout, see WithStatementTimeout
+func (c *Client) StatementTimeout() time.Duration {
+	return c.statementTimeout
+}
+
 func (c *Client) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	return c.beginTx(ctx, c.txOptions(opts))
+}
+
+// txOptions are the options of a transaction begun by the client
+type txOptions struct {
+	opts       *sql.TxOptions
+	deferrable bool          // only effective for serializable read only transactions
+	timeout    time.Duration // statement_timeout set for the duration of the transaction when timeoutSet
+	timeoutSet bool
+}
+
+// txOptions returns opts with the client's defaults
+func (c *Client) txOptions(opts *sql.TxOptions) txOptions {
+	return txOptions{opts: opts}
+}
+
+func (c *Client) beginTx(ctx context.Context, o txOptions) (*Tx, error) {
 	if c.DB == nil {
 		return nil, errors.New("db is nil")
 	}
 
-	tx, err := c.DB.BeginTx(ctx, opts)
+	if err := c.work.add(workTx); err != nil {
+		return nil, err
+	}
+
+	sqlTx, err := c.DB.BeginTx(ctx, o.opts)
 	if err != nil {
+		c.work.done(workTx)
 		return nil, err
 	}
 
-	return &Tx{Tx: tx}, nil
+	tx := &Tx{Tx: sqlTx, client: c}
+	tx.watch(ctx)
+	if o.opts != nil {
+		tx.isolation, tx.readOnly = o.opts.Isolation, o.opts.ReadOnly
+	}
+
+	if o.deferrable {
+		// must run before any query of the transaction
+		if _, err := sqlTx.ExecContext(ctx, "SET TRANSACTION DEFERRABLE"); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+		tx.deferrable = true
+	}
+
+	if o.timeoutSet {
+		if err := tx.setStatementTimeout(ctx, o.timeout); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+
+	return tx, nil
 }
 
+// RunInTransaction runs f in a transaction which is committed if f returns nil and rolled back otherwise.
+// The context passed to f carries the transaction, see ContextWithTx. A new transaction is begun even if
+// ctx already carries one, use the package level RunInTransaction to nest in a savepoint instead.
+//
+// The error returned by f is returned, as a *RollbackError if rolling back failed too. If f panics the
+// transaction is rolled back and the panic continues. f must not commit or roll back the transaction
+// itself, ErrTxEndedInCallback is returned if it does. The outcome is reported to the client's hooks
+// implementing TxHook.
 func (c *Client) RunInTransaction(ctx context.Context, f func(context.Context, *Tx) error, opts *sql.TxOptions) error {
-	tx, err := c.BeginTx(ctx, opts)
+	e := &TxEvent{Attempt: 1}
+	err := c.runTransaction(ctx, f, c.txOptions(opts), e)
+	c.afterTx(ctx, e)
+
+	return err
+}
+
+// runTransaction runs f in a transaction and records how it ended in e. When f panics e is passed to the
+// hooks before the panic continues, otherwise the caller reports e.
+func (c *Client) runTransaction(ctx context.Context, f func(context.Context, *Tx) error, o txOptions, e *TxEvent) error {
+	e.Start = time.Now()
+	defer func() {
+		e.Duration = time.Since(e.Start)
+	}()
+
+	tx, err := c.beginTx(ctx, o)
 	if err != nil {
+		e.Err = err
 		return err
 	}
 
@@ -72,17 +201,131 @@ func (c *Client) RunInTransaction(ctx context.Context, f func(context.Context, *
 	defer func() {
 		if !ranFunc {
 			// panicked in f still need to rollback transaction
-			tx.Rollback()
+			_ = tx.Rollback()
+			e.Outcome, e.Duration = TxPanicked, time.Since(e.Start)
+			c.afterTx(ctx, e)
 		}
 	}()
 
-	err = f(ctx, tx)
+	err = f(ContextWithTx(ctx, tx), tx)
 	ranFunc = true
-	if err != nil {
-		return tx.Rollback()
+
+	outcome, ctxDone := tx.state()
+	switch {
+	case outcome != "" && !ctxDone:
+		if err == nil {
+			err = ErrTxEndedInCallback
+		}
+	case err != nil:
+		// the transaction is already rolled back when ctx is done
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			err = &RollbackError{Err: err, RollbackErr: rbErr}
+		}
+	default:
+		err = tx.Commit()
+	}
+
+	e.Outcome, _ = tx.state()
+	e.Err = err
+
+	return err
+}
+
+// QueryContext calls the client's hooks around the DB's QueryContext, it runs in the ambient transaction
+// of ctx if any, see Resolve. Shutdown waits for it until it returns, not for the rows to be read.
+func (c *Client) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if tx, ok := c.ambientTx(ctx); ok {
+		return tx.QueryContext(ctx, query, args...)
+	}
+
+	if err := c.work.add(workQuery); err != nil {
+		return nil, err
+	}
+	defer c.work.done(workQuery)
+
+	var rows *sql.Rows
+	err := c.observe(ctx, newQueryEvent(ctx, query, args), func(ctx context.Context) error {
+		var err error
+		rows, err = c.DB.QueryContext(ctx, query, args...)
+		return err
+	})
+
+	return rows, err
+}
+
+// ExecContext calls the client's hooks around the DB's ExecContext, it runs in the ambient transaction
+// of ctx if any, see Resolve
+func (c *Client) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if tx, ok := c.ambientTx(ctx); ok {
+		return tx.ExecContext(ctx, query, args...)
+	}
+
+	if err := c.work.add(workQuery); err != nil {
+		return nil, err
+	}
+	defer c.work.done(workQuery)
+
+	var result sql.Result
+	e := newQueryEvent(ctx, query, args)
+	err := c.observe(ctx, e, func(ctx context.Context) error {
+		var err error
+		result, err = c.DB.ExecContext(ctx, query, args...)
+		if err == nil {
+			e.RowsAffected, _ = result.RowsAffected()
+		}
+		return err
+	})
+
+	return result, err
+}
+
+// QueryRowContext calls the client's hooks around the DB's QueryRowContext, it runs in the ambient
+// transaction of ctx if any, see Resolve
+func (c *Client) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if tx, ok := c.ambientTx(ctx); ok {
+		return tx.QueryRowContext(ctx, query, args...)
+	}
+
+	var row *sql.Row
+	_ = c.observe(ctx, newQueryEvent(ctx, query, args), func(ctx context.Context) error {
+		row = c.DB.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
+
+	return row
+}
+
+// PrepareContext calls the client's hooks around the DB's PrepareContext, it prepares in the ambient
+// transaction of ctx if any, see Resolve. Executions of the statement don't call the hooks.
+func (c *Client) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	if tx, ok := c.ambientTx(ctx); ok {
+		return tx.PrepareContext(ctx, query)
 	}
 
-	return tx.Commit()
+	var stmt *sql.Stmt
+	err := c.observe(ctx, newQueryEvent(ctx, query, nil), func(ctx context.Context) error {
+		var err error
+		stmt, err = c.DB.PrepareContext(ctx, query)
+		return err
+	})
+
+	return stmt, err
+}
+
+func (c *Client) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.QueryContext(context.Background(), query, args...)
+}
+
+func (c *Client) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.ExecContext(context.Background(), query, args...)
+}
+
+func (c *Client) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.QueryRowContext(context.Background(), query, args...)
+}
+
+func (c *Client) Prepare(query string) (*sql.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
 }
 
 func (c *Client) Select(tableName string, cols ...string) *Query {
@@ -150,7 +393,12 @@ func (c *Client) Name() string {
 	return "psql"
 }
 
-// Status calls the client's `Ping` method to determine connectivity.
+// Status returns a *HealthError when the client's Health is unhealthy, degraded clients are reported as
+// healthy.
 func (c *Client) Status(ctx context.Context) error {
-	return c.PingContext(ctx)
+	if r := c.Health(ctx); r.State == Unhealthy {
+		return &HealthError{Report: r}
+	}
+
+	return nil
 }
@@ -1,6 +1,7 @@
 package psql
 
 import (
+	"context"
 	"errors"
 	"reflect"
 
@@ -22,6 +23,16 @@ func (c *Client) BulkInsert(p BulkProvider) error {
 
 // Unlike the other Client funcs this executes immediately and does not return a Query that you Exec() on
 func (bi BulkInserter) BulkInsert(p BulkProvider) error {
+	if err := bi.client.work.add(workQuery); err != nil {
+		return err
+	}
+	defer bi.client.work.done(workQuery)
+
+	return bi.bulkInsert(p)
+}
+
+// bulkInsert runs BulkInsert without checking whether the client is shutting down
+func (bi BulkInserter) bulkInsert(p BulkProvider) error {
 	c := bi.client
 	m := p.NextModel()
 	if m == nil {
@@ -31,7 +42,11 @@ func (bi BulkInserter) BulkInsert(p BulkProvider) error {
 	buff := make([]Model, 0, p.Cap()+1)
 	buff = append(buff, m)
 
-	tx, err := c.DB.Begin()
+	// the transaction is rolled back when it fails or Shutdown abandons it
+	ctx, cancel := context.WithCancel(c.work.context())
+	defer cancel()
+
+	tx, err := c.DB.BeginTx(ctx, nil)
 	defer func() {
 		if err != nil && bi.errFunc != nil {
 			for _, m := range buff {
@@ -64,33 +79,18 @@ func (bi BulkInserter) BulkInsert(p BulkProvider) error {
 		fieldIdxs = append(fieldIdxs, idxs)
 	}
 
-	baseStmt := pq.CopyIn(m.TableName(), cols...)
-
-	stmt, err := tx.Prepare(baseStmt)
-	if err != nil {
-		return err
-	}
-
-	v := reflect.ValueOf(m)
-	if isPtr {
-		v = v.Elem()
-	}
-
-	attrs := make([]interface{}, 0, len(fieldIdxs))
-	for _, idxs := range fieldIdxs {
-		attrs = append(attrs, fieldAt(v, idxs).Interface())
+	var baseStmt string
+	if id := modelIdentifier(m); id.Schema != "" {
+		baseStmt = pq.CopyInSchema(id.Schema, id.Name, cols...)
+	} else {
+		baseStmt = pq.CopyIn(id.Name, cols...)
 	}
 
-	_, err = stmt.Exec(attrs...)
-	if err != nil {
-		return err
-	}
-
-	limit := p.Cap()
-	for i := 0; i < limit; i++ {
-		m := p.NextModel()
-		if m == nil {
-			break
+	e := &QueryEvent{Op: OpCopy, Table: modelTableName(m), SQL: baseStmt, RowsAffected: -1}
+	err = c.observe(context.Background(), e, func(context.Context) error {
+		stmt, err := tx.Prepare(baseStmt)
+		if err != nil {
+			return err
 		}
 
 		v := reflect.ValueOf(m)
@@ -105,30 +105,57 @@ func (bi BulkInserter) BulkInsert(p BulkProvider) error {
 
 		_, err = stmt.Exec(attrs...)
 		if err != nil {
-			if bi.errFunc != nil {
-				bi.errFunc(m, err)
+			return err
+		}
+
+		limit := p.Cap()
+		for i := 0; i < limit; i++ {
+			m := p.NextModel()
+			if m == nil {
+				break
 			}
 
-			continue
+			v := reflect.ValueOf(m)
+			if isPtr {
+				v = v.Elem()
+			}
+
+			attrs := make([]interface{}, 0, len(fieldIdxs))
+			for _, idxs := range fieldIdxs {
+				attrs = append(attrs, fieldAt(v, idxs).Interface())
+			}
+
+			_, err = stmt.Exec(attrs...)
+			if err != nil {
+				if bi.errFunc != nil {
+					bi.errFunc(m, wrapError(err))
+				}
+
+				continue
+			}
+
+			buff = append(buff, m)
 		}
 
-		buff = append(buff, m)
-	}
+		_, err = stmt.Exec()
+		if err != nil {
+			return err
+		}
+		err = stmt.Close()
+		if err != nil {
+			return err
+		}
+		err = tx.Commit()
+		if err != nil {
+			return err
+		}
 
-	_, err = stmt.Exec()
-	if err != nil {
-		return err
-	}
-	err = stmt.Close()
-	if err != nil {
-		return err
-	}
-	err = tx.Commit()
-	if err != nil {
-		return err
-	}
+		e.RowsAffected = int64(len(buff))
+
+		return nil
+	})
 
-	return nil
+	return err
 }
 
 func (bi BulkInserter) WithModelErrFunc(errFunc ModelErrorFunc) BulkInserter {
@@ -136,7 +163,14 @@ func (bi BulkInserter) WithModelErrFunc(errFunc ModelErrorFunc) BulkInserter {
 	return bi
 }
 
+// MonitorBulkInsertChannel bulk inserts the models sent on ch until it is closed. Shutdown waits for it to
+// return, it keeps inserting while the client shuts down.
 func (c *Client) MonitorBulkInsertChannel(ch chan Model, errFunc ModelErrorFunc) error {
+	if err := c.work.add(workMonitor); err != nil {
+		return err
+	}
+	defer c.work.done(workMonitor)
+
 	for {
 		m, ok := <-ch
 		if !ok {
@@ -144,7 +178,7 @@ func (c *Client) MonitorBulkInsertChannel(ch chan Model, errFunc ModelErrorFunc)
 		}
 
 		bi := c.BulkInserter().WithModelErrFunc(errFunc)
-		err := bi.BulkInsert(NewChannelModelProvider(m, ch))
+		err := bi.bulkInsert(NewChannelModelProvider(m, ch))
 		if err != nil {
 			return err
 		}
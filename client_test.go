@@ -817,6 +817,26 @@ func TestClient_RunInTransaction(t *testing.T) {
 				}, nil)
 			},
 		},
+		"committed in callback": {
+			Before: before,
+			Run: func(t *testing.T, c *Client) {
+				ctx := context.Background()
+
+				err := c.RunInTransaction(ctx, func(ctx context.Context, tx *Tx) error {
+					err := tx.Update(ctx, MockModel{ID: 1, IntField: 20}, "int_field")
+					require.Nil(t, err)
+
+					return tx.Commit()
+				}, nil)
+				require.Equal(t, ErrTxEndedInCallback, err)
+
+				var results []int
+				q := c.Select(MockModel{}.TableName(), "int_field")
+				err = q.OrderBy("int_field asc").Slice(ctx, &results)
+				require.Nil(t, err)
+				require.ElementsMatch(t, results, []int{10, 20})
+			},
+		},
 		"error returned": {
 			Before: before,
 			Run: func(t *testing.T, c *Client) {
@@ -831,7 +851,7 @@ func TestClient_RunInTransaction(t *testing.T) {
 
 					return errors.New("some error returned")
 				}, nil)
-				require.Nil(t, err)
+				require.EqualError(t, err, "some error returned")
 
 				var results []int
 				q := c.Select(MockModel{}.TableName(), "int_field")
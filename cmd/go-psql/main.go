@@ -0,0 +1,163 @@
+// Command go-psql runs SQL migrations and other schema tasks with the connection settings used by go-psql
+// clients: the -url flag, or DATABASE_URL and the PG* environment variables.
+//
+//	go-psql new [-dir migrations] <name>
+//	go-psql up [-dir migrations] [-dry-run]
+//	go-psql down [-dir migrations] [-to version] [-dry-run]
+//	go-psql status [-dir migrations]
+//	go-psql dump [-o file]
+//	go-psql health [-wait]
+//
+// Go function migrations are only run by services using the migrate package.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	psql "github.com/airspacetechnologies/go-psql"
+)
+
+const usage = `usage: go-psql <command> [flags]
+
+commands:
+  new     create the up and down files of a new migration
+  up      apply the pending migrations
+  down    roll back the last migration, or those newer than -to
+  status  list the migrations and whether they are applied
+  dump    write the schema with pg_dump
+  health  check connectivity and the health of the server
+
+run go-psql <command> -h for the flags of a command`
+
+// errUsage is returned for invalid arguments, the usage was already written
+var errUsage = errors.New("invalid arguments")
+
+func main() {
+	err := run(context.Background(), os.Args[1:], os.Stdout, os.Stderr)
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	default:
+		fmt.Fprintln(os.Stderr, "go-psql:", err)
+		os.Exit(1)
+	}
+}
+
+// options holds the flags shared by the commands
+type options struct {
+	url     string
+	dir     string
+	timeout time.Duration
+}
+
+func (o *options) connFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.url, "url", "", "connection url, DATABASE_URL and the PG* environment variables are used when empty")
+	fs.DurationVar(&o.timeout, "timeout", 5*time.Minute, "time limit of the command")
+}
+
+func (o *options) dirFlag(fs *flag.FlagSet) {
+	fs.StringVar(&o.dir, "dir", "migrations", "directory of the migration files")
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, usage)
+		return errUsage
+	}
+
+	name, args := args[0], args[1:]
+	fs := flag.NewFlagSet("go-psql "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var cmd func(context.Context, *options, []string, io.Writer) error
+	o := &options{}
+
+	switch name {
+	case "new":
+		o.dirFlag(fs)
+		cmd = newMigration
+	case "up":
+		o.dirFlag(fs)
+		o.connFlags(fs)
+		dryRun := fs.Bool("dry-run", false, "list the migrations without applying them")
+		cmd = func(ctx context.Context, o *options, args []string, w io.Writer) error {
+			return up(ctx, o, *dryRun, w)
+		}
+	case "down":
+		o.dirFlag(fs)
+		o.connFlags(fs)
+		dryRun := fs.Bool("dry-run", false, "list the migrations without rolling them back")
+		to := fs.Int64("to", -1, "roll back the migrations newer than this version instead of the last one")
+		cmd = func(ctx context.Context, o *options, args []string, w io.Writer) error {
+			return down(ctx, o, *to, *dryRun, w)
+		}
+	case "status":
+		o.dirFlag(fs)
+		o.connFlags(fs)
+		cmd = status
+	case "dump":
+		o.connFlags(fs)
+		out := fs.String("o", "", "file to write the schema to instead of stdout")
+		cmd = func(ctx context.Context, o *options, args []string, w io.Writer) error {
+			return dump(ctx, o, *out, w)
+		}
+	case "health":
+		o.connFlags(fs)
+		wait := fs.Bool("wait", false, "wait for the server to accept connections until the timeout")
+		cmd = func(ctx context.Context, o *options, args []string, w io.Writer) error {
+			return health(ctx, o, *wait, w)
+		}
+	case "-h", "-help", "--help", "help":
+		fmt.Fprintln(stdout, usage)
+		return nil
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%v\n", name, usage)
+		return errUsage
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+
+	return cmd(ctx, o, fs.Args(), stdout)
+}
+
+// config returns the config of url, or of the environment when it is empty
+func (o *options) config() (*psql.Config, error) {
+	if o.url != "" {
+		return psql.ParseConfig(o.url)
+	}
+
+	return psql.ConfigFromEnv()
+}
+
+// connect starts a client, waiting for the server until ctx is done when wait is set
+func (o *options) connect(ctx context.Context, wait bool) (*psql.Client, error) {
+	cfg, err := o.config()
+	if err != nil {
+		return nil, err
+	}
+
+	c := psql.NewClient(cfg)
+	if err := c.StartContext(ctx, psql.StartOptions{WaitForServer: wait}); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	psql "github.com/airspacetechnologies/go-psql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_New(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "migrations")
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	require.Nil(t, run(ctx, []string{"new", "-dir", dir, "Create Flights"}, &out, &out))
+	require.Nil(t, run(ctx, []string{"new", "-dir", dir, "add_gate"}, &out, &out))
+
+	require.Equal(t, strings.Join([]string{
+		"created " + filepath.Join(dir, "0001_create_flights.up.sql"),
+		"created " + filepath.Join(dir, "0001_create_flights.down.sql"),
+		"created " + filepath.Join(dir, "0002_add_gate.up.sql"),
+		"created " + filepath.Join(dir, "0002_add_gate.down.sql"),
+	}, "\n")+"\n", out.String())
+
+	b, err := os.ReadFile(filepath.Join(dir, "0002_add_gate.down.sql"))
+	require.Nil(t, err)
+	require.Equal(t, "-- add_gate down\n", string(b))
+
+	require.EqualError(t, run(ctx, []string{"new", "-dir", dir, "add-gate"}, &out, &out),
+		`migration name "add-gate" must only contain letters, digits and underscores`)
+	require.NotNil(t, run(ctx, []string{"new", "-dir", dir}, &out, &out))
+}
+
+func TestRun_Usage(t *testing.T) {
+	var out bytes.Buffer
+	require.Equal(t, errUsage, run(context.Background(), nil, &out, &out))
+	require.Equal(t, errUsage, run(context.Background(), []string{"migrate"}, &out, &out))
+	require.Equal(t, errUsage, run(context.Background(), []string{"up", "-force"}, &out, &out))
+	require.Contains(t, out.String(), `unknown command "migrate"`)
+}
+
+func TestPgDumpEnv(t *testing.T) {
+	cfg := &psql.Config{Host: "db", Port: "5433", User: "bob", Password: "secret", DbName: "flights", SSLMode: "require"}
+	require.Equal(t, []string{
+		"PGHOST=db", "PGPORT=5433", "PGUSER=bob", "PGPASSWORD=secret", "PGDATABASE=flights", "PGSSLMODE=require",
+	}, pgDumpEnv(cfg))
+}
+
+func TestRun_Migrations(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	require.Nil(t, run(ctx, []string{"new", "-dir", dir, "create_cmd_flights"}, &out, &out))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "0001_create_cmd_flights.up.sql"), []byte("CREATE TABLE cmd_flights ();"), 0644))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "0001_create_cmd_flights.down.sql"), []byte("DROP TABLE cmd_flights;"), 0644))
+
+	out.Reset()
+	require.Nil(t, run(ctx, []string{"up", "-dir", dir, "-dry-run"}, &out, &out))
+	require.Equal(t, "would apply 1_create_cmd_flights\n", out.String())
+
+	out.Reset()
+	require.Nil(t, run(ctx, []string{"up", "-dir", dir}, &out, &out))
+	require.Equal(t, "applied 1_create_cmd_flights\n", out.String())
+
+	out.Reset()
+	require.Nil(t, run(ctx, []string{"status", "-dir", dir}, &out, &out))
+	require.Contains(t, out.String(), "create_cmd_flights  applied")
+
+	out.Reset()
+	require.Nil(t, run(ctx, []string{"down", "-dir", dir}, &out, &out))
+	require.Equal(t, "rolled back 1_create_cmd_flights\n", out.String())
+
+	out.Reset()
+	require.Nil(t, run(ctx, []string{"health"}, &out, &out))
+	require.True(t, strings.HasPrefix(out.String(), "state: "))
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	psql "github.com/airspacetechnologies/go-psql"
+	"github.com/airspacetechnologies/go-psql/migrate"
+)
+
+var migrationNameRegexp = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// newMigration creates the files of the next version in the migrations directory
+func newMigration(_ context.Context, o *options, args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("new expects the name of the migration, e.g. go-psql new create_flights")
+	}
+
+	name := strings.ToLower(strings.Join(strings.Fields(args[0]), "_"))
+	if !migrationNameRegexp.MatchString(name) {
+		return fmt.Errorf("migration name %q must only contain letters, digits and underscores", args[0])
+	}
+
+	if err := os.MkdirAll(o.dir, 0755); err != nil {
+		return err
+	}
+
+	migrations, err := migrate.ReadDir(o.dir)
+	if err != nil {
+		return err
+	}
+
+	var version int64 = 1
+	if n := len(migrations); n > 0 {
+		version = migrations[n-1].Version + 1
+	}
+
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(o.dir, fmt.Sprintf("%04d_%v.%v.sql", version, name, direction))
+
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(f, "-- %v %v\n", name, direction)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w, "created", path)
+	}
+
+	return nil
+}
+
+func (o *options) migrator(ctx context.Context, dryRun bool) (*psql.Client, *migrate.Migrator, error) {
+	migrations, err := migrate.ReadDir(o.dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c, err := o.connect(ctx, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var opts []migrate.Option
+	if dryRun {
+		opts = append(opts, migrate.WithDryRun())
+	}
+
+	m, err := migrate.New(c, migrations, opts...)
+	if err != nil {
+		_ = c.Close()
+		return nil, nil, err
+	}
+
+	return c, m, nil
+}
+
+func up(ctx context.Context, o *options, dryRun bool, w io.Writer) error {
+	c, m, err := o.migrator(ctx, dryRun)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	ran, err := m.Up(ctx)
+	report(w, ran, dryRun, "apply", "applied")
+
+	return err
+}
+
+// down rolls back the migrations newer than to, or the last applied one when to is negative
+func down(ctx context.Context, o *options, to int64, dryRun bool, w io.Writer) error {
+	c, m, err := o.migrator(ctx, dryRun)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if to < 0 {
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		var applied []int64
+		for _, s := range statuses {
+			if s.Applied {
+				applied = append(applied, s.Version)
+			}
+		}
+
+		switch len(applied) {
+		case 0:
+			fmt.Fprintln(w, "no applied migrations")
+			return nil
+		case 1:
+			to = 0
+		default:
+			to = applied[len(applied)-2]
+		}
+	}
+
+	ran, err := m.Down(ctx, to)
+	report(w, ran, dryRun, "roll back", "rolled back")
+
+	return err
+}
+
+func report(w io.Writer, migrations []*migrate.Migration, dryRun bool, plan, done string) {
+	if len(migrations) == 0 {
+		fmt.Fprintln(w, "no migrations to", plan)
+		return
+	}
+
+	for _, m := range migrations {
+		if dryRun {
+			fmt.Fprintln(w, "would", plan, m)
+		} else {
+			fmt.Fprintln(w, done, m)
+		}
+	}
+}
+
+func status(ctx context.Context, o *options, _ []string, w io.Writer) error {
+	c, m, err := o.migrator(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+
+	for _, s := range statuses {
+		state, appliedAt := "pending", ""
+		switch {
+		case s.Migration == nil:
+			state = "unknown"
+		case s.Changed:
+			state = "changed"
+		case s.Applied:
+			state = "applied"
+		}
+
+		if s.Applied {
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+		}
+
+		fmt.Fprintf(tw, "%d\t%v\t%v\t%v\n", s.Version, s.Name, state, appliedAt)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	psql "github.com/airspacetechnologies/go-psql"
+)
+
+// dump writes the schema with pg_dump, which must be on the PATH
+func dump(ctx context.Context, o *options, out string, w io.Writer) error {
+	cfg, err := o.config()
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	args := []string{"--schema-only", "--no-owner", "--no-privileges"}
+	if cfg.Schema != "" {
+		args = append(args, "--schema="+cfg.Schema)
+	}
+	if out != "" {
+		args = append(args, "--file="+out)
+	}
+
+	cmd := exec.CommandContext(ctx, "pg_dump", args...)
+	cmd.Env = append(os.Environ(), pgDumpEnv(cfg)...)
+	cmd.Stdout, cmd.Stderr = w, os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("pg_dump: %w", err)
+	}
+
+	return nil
+}
+
+// pgDumpEnv returns the environment passing the connection settings of cfg to pg_dump, which keeps the
+// password out of its arguments
+func pgDumpEnv(cfg *psql.Config) []string {
+	vars := []struct {
+		name, val string
+	}{
+		{"PGHOST", cfg.Host},
+		{"PGPORT", cfg.Port},
+		{"PGUSER", cfg.User},
+		{"PGPASSWORD", cfg.Password},
+		{"PGDATABASE", cfg.DbName},
+		{"PGSSLMODE", cfg.SSLMode},
+		{"PGSSLROOTCERT", cfg.SSLRootCert},
+		{"PGSSLCERT", cfg.SSLCert},
+		{"PGSSLKEY", cfg.SSLKey},
+		{"PGCONNECT_TIMEOUT", cfg.ConnectTimeout},
+		{"PGAPPNAME", cfg.ApplicationName},
+	}
+
+	var env []string
+	for _, v := range vars {
+		if v.val != "" {
+			env = append(env, v.name+"="+v.val)
+		}
+	}
+
+	return env
+}
+
+// health prints the health report of the server, it fails when the server is unhealthy
+func health(ctx context.Context, o *options, wait bool, w io.Writer) error {
+	c, err := o.connect(ctx, wait)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	r := c.Health(ctx)
+
+	fmt.Fprintln(w, "state:", r.State)
+	if len(r.Reasons) > 0 {
+		fmt.Fprintln(w, "reasons:", strings.Join(r.Reasons, "; "))
+	}
+
+	if r.Err == nil {
+		fmt.Fprintln(w, "server version:", r.ServerVersion)
+		fmt.Fprintln(w, "latency:", r.Latency)
+		fmt.Fprintf(w, "pool: %d/%d connections in use\n", r.Pool.InUse, r.Pool.MaxOpenConnections)
+		if r.InRecovery {
+			fmt.Fprintln(w, "replica, replication lag:", r.ReplicationLag)
+		}
+	}
+
+	if r.State == psql.Unhealthy {
+		return &psql.HealthError{Report: r}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package psql
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Errors matching postgres errors by SQLSTATE, use with errors.Is:
+//
+//	if errors.Is(err, psql.ErrUniqueViolation) { ... }
+//
+// errors.As with *Error gives access to the table, constraint, column and key.
+var (
+	ErrUniqueViolation      = errors.New("unique violation")      // 23505
+	ErrForeignKeyViolation  = errors.New("foreign key violation") // 23503
+	ErrNotNullViolation     = errors.New("not null violation")    // 23502
+	ErrCheckViolation       = errors.New("check violation")       // 23514
+	ErrExclusionViolation   = errors.New("exclusion violation")   // 23P01
+	ErrSerializationFailure = errors.New("serialization failure") // 40001
+	ErrDeadlock             = errors.New("deadlock detected")     // 40P01
+	ErrReadOnlyTransaction  = errors.New("read only transaction") // 25006
+	ErrLockTimeout          = errors.New("lock timeout")          // 55P03
+	ErrQueryCanceled        = errors.New("query canceled")        // 57014, also matches ErrStatementTimeout errors
+)
+
+var errorKinds = map[pq.ErrorCode]error{
+	"23505": ErrUniqueViolation,
+	"23503": ErrForeignKeyViolation,
+	"23502": ErrNotNullViolation,
+	"23514": ErrCheckViolation,
+	"23P01": ErrExclusionViolation,
+	"40001": ErrSerializationFailure,
+	"40P01": ErrDeadlock,
+	"25006": ErrReadOnlyTransaction,
+	"55P03": ErrLockTimeout,
+	"57014": ErrQueryCanceled,
+}
+
+var keyDetailRegexp = regexp.MustCompile(`^Key \((.+?)\)=\((.*)\)`)
+
+// Error wraps a *pq.Error whose SQLSTATE has one of the Err kinds above
+type Error struct {
+	Kind error // one of the Err values this error matches with errors.Is
+	Code pq.ErrorCode
+
+	Schema     string
+	Table      string
+	Constraint string
+	Column     string
+
+	// Key holds the conflicting key of unique and foreign key violations parsed from the detail,
+	// e.g. `Key (email)=(a@b.c) already exists.` gives {"email": "a@b.c"}
+	Key map[string]string
+
+	Err *pq.Error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// wrapError wraps postgres errors with a known SQLSTATE in an *Error, also when they are already wrapped,
+// e.g. by a hook
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	kind, ok := errorKinds[pqErr.Code]
+	if !ok {
+		return err
+	}
+
+	return &Error{
+		Kind:       kind,
+		Code:       pqErr.Code,
+		Schema:     pqErr.Schema,
+		Table:      pqErr.Table,
+		Constraint: pqErr.Constraint,
+		Column:     pqErr.Column,
+		Key:        parseKeyDetail(pqErr.Detail),
+		Err:        pqErr,
+	}
+}
+
+// translateError wraps an error from running a statement into *Error and TimeoutError
+func translateError(err error, timeout time.Duration) error {
+	return timeoutError(wrapError(err), timeout)
+}
+
+// parseKeyDetail parses details like `Key (a, b)=(1, 2) already exists.`, values containing
+// the separator are only supported for single column keys
+func parseKeyDetail(detail string) map[string]string {
+	m := keyDetailRegexp.FindStringSubmatch(detail)
+	if m == nil {
+		return nil
+	}
+
+	cols := strings.Split(m[1], ", ")
+	if len(cols) == 1 {
+		return map[string]string{cols[0]: m[2]}
+	}
+
+	vals := strings.Split(m[2], ", ")
+	if len(vals) != len(cols) {
+		return nil
+	}
+
+	key := make(map[string]string, len(cols))
+	for i, col := range cols {
+		key[col] = vals[i]
+	}
+
+	return key
+}
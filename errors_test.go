@@ -0,0 +1,170 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapError(t *testing.T) {
+	tcs := map[string]struct {
+		Err      *pq.Error
+		Kind     error
+		Expected *Error
+	}{
+		"unique": {
+			Err: &pq.Error{
+				Code:       "23505",
+				Table:      "users",
+				Constraint: "users_email_key",
+				Detail:     "Key (email)=(a@b.c) already exists.",
+			},
+			Kind: ErrUniqueViolation,
+			Expected: &Error{
+				Kind:       ErrUniqueViolation,
+				Code:       "23505",
+				Table:      "users",
+				Constraint: "users_email_key",
+				Key:        map[string]string{"email": "a@b.c"},
+			},
+		},
+		"unique multiple columns": {
+			Err:  &pq.Error{Code: "23505", Detail: "Key (a, b)=(1, x) already exists."},
+			Kind: ErrUniqueViolation,
+			Expected: &Error{
+				Kind: ErrUniqueViolation,
+				Code: "23505",
+				Key:  map[string]string{"a": "1", "b": "x"},
+			},
+		},
+		"foreign key": {
+			Err:  &pq.Error{Code: "23503", Table: "posts", Detail: `Key (user_id)=(5) is not present in table "users".`},
+			Kind: ErrForeignKeyViolation,
+			Expected: &Error{
+				Kind:  ErrForeignKeyViolation,
+				Code:  "23503",
+				Table: "posts",
+				Key:   map[string]string{"user_id": "5"},
+			},
+		},
+		"not null": {
+			Err:      &pq.Error{Code: "23502", Table: "users", Column: "email"},
+			Kind:     ErrNotNullViolation,
+			Expected: &Error{Kind: ErrNotNullViolation, Code: "23502", Table: "users", Column: "email"},
+		},
+		"check":         {Err: &pq.Error{Code: "23514"}, Kind: ErrCheckViolation},
+		"exclusion":     {Err: &pq.Error{Code: "23P01"}, Kind: ErrExclusionViolation},
+		"serialization": {Err: &pq.Error{Code: "40001"}, Kind: ErrSerializationFailure},
+		"deadlock":      {Err: &pq.Error{Code: "40P01"}, Kind: ErrDeadlock},
+		"read only":     {Err: &pq.Error{Code: "25006"}, Kind: ErrReadOnlyTransaction},
+		"lock timeout":  {Err: &pq.Error{Code: "55P03"}, Kind: ErrLockTimeout},
+		"canceled":      {Err: &pq.Error{Code: "57014"}, Kind: ErrQueryCanceled},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			err := wrapError(tc.Err)
+			require.True(t, errors.Is(err, tc.Kind))
+
+			var e *Error
+			require.True(t, errors.As(err, &e))
+
+			var pqErr *pq.Error
+			require.True(t, errors.As(err, &pqErr))
+			require.Equal(t, tc.Err, pqErr)
+			require.Equal(t, tc.Err.Error(), err.Error())
+
+			if tc.Expected != nil {
+				tc.Expected.Err = tc.Err
+				require.Equal(t, tc.Expected, e)
+			}
+
+			for _, kind := range errorKinds {
+				if kind != tc.Kind {
+					require.False(t, errors.Is(err, kind))
+				}
+			}
+
+			// not wrapped twice
+			require.Equal(t, err, wrapError(err))
+		})
+	}
+
+	other := &pq.Error{Code: "42P01"}
+	require.Equal(t, error(other), wrapError(other))
+	require.Nil(t, wrapError(nil))
+
+	// already wrapped errors are translated
+	pqErr := &pq.Error{Code: "23505"}
+	err := wrapError(fmt.Errorf("hook: %w", pqErr))
+	require.True(t, errors.Is(err, ErrUniqueViolation))
+	require.Equal(t, &Error{Kind: ErrUniqueViolation, Code: "23505", Err: pqErr}, err)
+}
+
+func TestTranslateError_Timeout(t *testing.T) {
+	err := translateError(&pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"}, time.Second)
+	require.True(t, errors.Is(err, ErrStatementTimeout))
+	require.True(t, errors.Is(err, ErrQueryCanceled))
+}
+
+func TestClient_TypedErrors(t *testing.T) {
+	c := NewClient(nil)
+
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+
+	if _, err := c.Exec(modelsTable); err != nil {
+		t.Fatalf("failed to create table %v", err)
+	}
+
+	defer func() {
+		_, _ = c.Exec("drop table mock_models")
+		_ = c.Close()
+	}()
+
+	_, err := c.Exec("create unique index mock_models_string_field_key on mock_models (string_field)")
+	require.Nil(t, err)
+
+	_, err = c.Exec("alter table mock_models add constraint int_field_positive check (int_field > 0)")
+	require.Nil(t, err)
+
+	ctx := context.Background()
+
+	require.Nil(t, c.Insert(ctx, &MockModel{StringField: "a", IntField: 1}))
+
+	err = c.Insert(ctx, &MockModel{StringField: "a", IntField: 1})
+	require.True(t, errors.Is(err, ErrUniqueViolation), fmt.Sprint(err))
+
+	var e *Error
+	require.True(t, errors.As(err, &e))
+	require.Equal(t, "mock_models", e.Table)
+	require.Equal(t, "mock_models_string_field_key", e.Constraint)
+	require.Equal(t, map[string]string{"string_field": "a"}, e.Key)
+
+	var qe *QueryError
+	require.True(t, errors.As(err, &qe))
+	require.Equal(t, OpInsert, qe.Op)
+	require.Equal(t, "mock_models", qe.Table)
+	require.True(t, strings.HasPrefix(qe.SQL, `INSERT INTO "mock_models"`), qe.SQL)
+	require.Equal(t, "Key (string_field)=(a) already exists.", qe.Detail)
+
+	_, err = c.UpdateAll("mock_models", Attrs{"int_field": -1}).Exec(ctx)
+	require.True(t, errors.Is(err, ErrCheckViolation), fmt.Sprint(err))
+	require.True(t, errors.As(err, &qe))
+	require.Equal(t, OpUpdate, qe.Op)
+
+	_, err = RawQuery(ctx, c, "select * from mock_models where nope = 1")
+	require.True(t, errors.As(err, &qe))
+	require.Equal(t, OpSelect, qe.Op)
+	require.Equal(t, 33, qe.Position)
+
+	err = c.BulkInsert(NewSliceModelProvider([]Model{&MockModel{StringField: "b", IntField: 1}, &MockModel{StringField: "b", IntField: 1}}))
+	require.True(t, errors.Is(err, ErrUniqueViolation), fmt.Sprint(err))
+}
@@ -0,0 +1,168 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// HealthState summarizes a HealthReport
+type HealthState string
+
+const (
+	Healthy   HealthState = "healthy"
+	Degraded  HealthState = "degraded"
+	Unhealthy HealthState = "unhealthy" // also when the server can't be reached
+)
+
+// HealthThresholds turn the measurements of a HealthReport into degraded and unhealthy states, zero values
+// aren't checked
+type HealthThresholds struct {
+	DegradedLatency  time.Duration
+	UnhealthyLatency time.Duration
+
+	// saturation is the share of MaxOpenConns in use, it isn't checked for unlimited pools
+	DegradedPoolSaturation  float64
+	UnhealthyPoolSaturation float64
+
+	// only checked when the server is a replica
+	DegradedReplicationLag  time.Duration
+	UnhealthyReplicationLag time.Duration
+}
+
+// DefaultHealthThresholds are used by clients created with NewClient unless WithHealthThresholds is given,
+// they check nothing so Status only fails when the server can't be reached
+var DefaultHealthThresholds = HealthThresholds{}
+
+// WithHealthThresholds sets the thresholds used by Health and Status
+func WithHealthThresholds(t HealthThresholds) ClientOption {
+	return func(c *Client) {
+		c.healthThresholds = t
+	}
+}
+
+// HealthReport describes the health of a client's server and connection pool, see Client.Health
+type HealthReport struct {
+	State   HealthState
+	Reasons []string // why the state isn't Healthy
+
+	Latency        time.Duration // round trip of the health query
+	Pool           sql.DBStats
+	PoolSaturation float64 // InUse / MaxOpenConnections, 0 for unlimited pools
+
+	ServerVersion    string        // e.g. "10.6"
+	ServerVersionNum int           // e.g. 100006
+	InRecovery       bool          // the server is a replica
+	ReplicationLag   time.Duration // since the last replayed transaction, 0 when all received WAL is replayed
+
+	Replicas []*HealthReport // of the replicas, see WithReplicas
+
+	Err error // the error reaching the server
+}
+
+// HealthError is returned by Status when the client is unhealthy
+type HealthError struct {
+	Report *HealthReport
+}
+
+func (e *HealthError) Error() string {
+	return "psql unhealthy: " + strings.Join(e.Report.Reasons, "; ")
+}
+
+func (e *HealthError) Unwrap() error {
+	return e.Report.Err
+}
+
+// Health queries the server once for its version, recovery state and replication lag, and reports them
+// with the round trip latency and the pool statistics. The state is set by the client's HealthThresholds,
+// a report of an unhealthy replica degrades the client's state since reads fall back to the primary.
+func (c *Client) Health(ctx context.Context) *HealthReport {
+	r := &HealthReport{State: Healthy}
+
+	if c.DB == nil {
+		r.unhealthy(errors.New("db is nil"))
+		return r
+	}
+
+	r.Pool = c.DB.Stats()
+	if r.Pool.MaxOpenConnections > 0 {
+		r.PoolSaturation = float64(r.Pool.InUse) / float64(r.Pool.MaxOpenConnections)
+	}
+
+	var lag sql.NullFloat64
+	start := time.Now()
+	// a replica that replayed everything it received isn't behind even if the primary was idle since
+	err := c.QueryRowContext(WithoutTx(ctx), `SELECT current_setting('server_version'), current_setting('server_version_num')::int,
+		pg_is_in_recovery(), CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE extract(epoch FROM now() - pg_last_xact_replay_timestamp()) END`,
+	).Scan(&r.ServerVersion, &r.ServerVersionNum, &r.InRecovery, &lag)
+	r.Latency = time.Since(start)
+
+	if err != nil {
+		r.unhealthy(err)
+		return r
+	}
+
+	if r.InRecovery && lag.Valid {
+		r.ReplicationLag = time.Duration(lag.Float64 * float64(time.Second))
+	}
+
+	t := c.healthThresholds
+	r.check("latency", r.Latency, t.DegradedLatency, t.UnhealthyLatency)
+	r.checkSaturation(t.DegradedPoolSaturation, t.UnhealthyPoolSaturation)
+	if r.InRecovery {
+		r.check("replication lag", r.ReplicationLag, t.DegradedReplicationLag, t.UnhealthyReplicationLag)
+	}
+
+	if c.replicas != nil {
+		for i, replica := range c.replicas.replicas {
+			rr := replica.Health(ctx)
+			r.Replicas = append(r.Replicas, rr)
+
+			if rr.State == Unhealthy {
+				r.degrade(Degraded, fmt.Sprintf("replica %d is unhealthy", i+1))
+			}
+		}
+	}
+
+	return r
+}
+
+func (r *HealthReport) unhealthy(err error) {
+	r.Err = err
+	r.degrade(Unhealthy, fmt.Sprintf("unreachable: %v", err))
+}
+
+// degrade records reason and lowers the state to s unless it is already worse
+func (r *HealthReport) degrade(s HealthState, reason string) {
+	r.Reasons = append(r.Reasons, reason)
+	if r.State != Unhealthy {
+		r.State = s
+	}
+}
+
+func (r *HealthReport) check(name string, d, degraded, unhealthy time.Duration) {
+	switch {
+	case unhealthy > 0 && d >= unhealthy:
+		r.degrade(Unhealthy, fmt.Sprintf("%v %v exceeds %v", name, d, unhealthy))
+	case degraded > 0 && d >= degraded:
+		r.degrade(Degraded, fmt.Sprintf("%v %v exceeds %v", name, d, degraded))
+	}
+}
+
+func (r *HealthReport) checkSaturation(degraded, unhealthy float64) {
+	if r.Pool.MaxOpenConnections <= 0 {
+		return
+	}
+
+	reason := fmt.Sprintf("pool saturation %d/%d connections in use", r.Pool.InUse, r.Pool.MaxOpenConnections)
+	switch {
+	case unhealthy > 0 && r.PoolSaturation >= unhealthy:
+		r.degrade(Unhealthy, reason)
+	case degraded > 0 && r.PoolSaturation >= degraded:
+		r.degrade(Degraded, reason)
+	}
+}
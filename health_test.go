@@ -0,0 +1,104 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthReport_Thresholds(t *testing.T) {
+	r := &HealthReport{State: Healthy}
+	r.check("latency", 50*time.Millisecond, 100*time.Millisecond, time.Second)
+	require.Equal(t, Healthy, r.State)
+	require.Empty(t, r.Reasons)
+
+	r.check("latency", 200*time.Millisecond, 100*time.Millisecond, time.Second)
+	require.Equal(t, Degraded, r.State)
+
+	r.check("replication lag", 2*time.Minute, 10*time.Second, time.Minute)
+	require.Equal(t, Unhealthy, r.State)
+
+	// degraded checks don't improve the state
+	r.Pool.MaxOpenConnections, r.Pool.InUse, r.PoolSaturation = 10, 9, 0.9
+	r.checkSaturation(0.8, 0)
+	require.Equal(t, Unhealthy, r.State)
+	require.Equal(t, []string{
+		"latency 200ms exceeds 100ms",
+		"replication lag 2m0s exceeds 1m0s",
+		"pool saturation 9/10 connections in use",
+	}, r.Reasons)
+
+	// zero thresholds and unlimited pools aren't checked
+	r = &HealthReport{State: Healthy}
+	r.check("latency", time.Hour, 0, 0)
+	r.Pool.InUse = 100
+	r.checkSaturation(0.1, 0.2)
+	require.Equal(t, Healthy, r.State)
+}
+
+func TestClient_HealthUnreachable(t *testing.T) {
+	c := NewClient(nil)
+	r := c.Health(context.Background())
+	require.Equal(t, Unhealthy, r.State)
+	require.EqualError(t, c.Status(context.Background()), "psql unhealthy: unreachable: db is nil")
+
+	c = NewClient(&Config{Host: "127.0.0.1", Port: "1", SSLMode: "disable"})
+	require.Nil(t, c.Start(""))
+	defer c.Close()
+
+	r = c.Health(context.Background())
+	require.Equal(t, Unhealthy, r.State)
+	require.NotNil(t, r.Err)
+
+	err := c.Status(context.Background())
+	var healthErr *HealthError
+	require.True(t, errors.As(err, &healthErr))
+	require.Equal(t, r.Err.Error(), errors.Unwrap(err).Error())
+}
+
+func TestClient_Health(t *testing.T) {
+	c := NewClient(nil, WithHealthThresholds(HealthThresholds{DegradedPoolSaturation: 0.5}))
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	r := c.Health(ctx)
+	require.Nil(t, r.Err)
+	require.Equal(t, Healthy, r.State)
+	require.NotEmpty(t, r.ServerVersion)
+	require.Greater(t, r.ServerVersionNum, 90000)
+	require.False(t, r.InRecovery)
+	require.Greater(t, r.Latency, time.Duration(0))
+	require.Nil(t, c.Status(ctx))
+
+	c.SetMaxOpenConns(2)
+	tx, err := c.BeginTx(ctx, nil)
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	r = c.Health(ctx)
+	require.Equal(t, Degraded, r.State)
+	require.Equal(t, []string{"pool saturation 1/2 connections in use"}, r.Reasons)
+	require.Nil(t, c.Status(ctx))
+
+	// the default thresholds check nothing
+	d := NewClient(nil)
+	if err := d.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+	defer d.Close()
+
+	d.SetMaxOpenConns(2)
+	dtx, err := d.BeginTx(ctx, nil)
+	require.Nil(t, err)
+	defer dtx.Rollback()
+
+	r = d.Health(ctx)
+	require.Equal(t, Healthy, r.State)
+	require.Empty(t, r.Reasons)
+}
@@ -0,0 +1,184 @@
+package psql
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Operation is the kind of statement described by a QueryEvent
+type Operation string
+
+const (
+	OpSelect Operation = "select"
+	OpInsert Operation = "insert"
+	OpUpdate Operation = "update"
+	OpDelete Operation = "delete"
+	OpCopy   Operation = "copy"
+	OpExec   Operation = "exec" // any other statement
+)
+
+// QueryEvent describes a statement passed to the hooks of a Client.
+// Duration, RowsAffected and Err are set before AfterQuery is called.
+type QueryEvent struct {
+	Op    Operation
+	Table string // empty for raw queries
+	SQL   string
+	Args  []interface{}
+
+	// ArgColumns holds the column (or named parameter) each of Args is bound to when built by a Query,
+	// empty for raw values
+	ArgColumns []string
+
+	Start        time.Time
+	Duration     time.Duration
+	RowsAffected int64 // -1 for statements returning rows since they have not been read yet
+	Err          error
+}
+
+// Hook is called around every statement run through a Client, its transactions and bulk inserts.
+//
+// BeforeQuery is called in the order the hooks were added and can return a derived context
+// (e.g. holding a tracing span) which is passed to the statement and the hook's AfterQuery.
+// AfterQuery is called in the reverse order.
+type Hook interface {
+	BeforeQuery(ctx context.Context, e *QueryEvent) context.Context
+	AfterQuery(ctx context.Context, e *QueryEvent)
+}
+
+// HookFuncs adapts functions to a Hook, either may be nil
+type HookFuncs struct {
+	Before func(context.Context, *QueryEvent) context.Context
+	After  func(context.Context, *QueryEvent)
+}
+
+func (h HookFuncs) BeforeQuery(ctx context.Context, e *QueryEvent) context.Context {
+	if h.Before == nil {
+		return ctx
+	}
+
+	return h.Before(ctx, e)
+}
+
+func (h HookFuncs) AfterQuery(ctx context.Context, e *QueryEvent) {
+	if h.After != nil {
+		h.After(ctx, e)
+	}
+}
+
+// TxOutcome is how a transaction run by RunInTransaction ended
+type TxOutcome string
+
+const (
+	TxCommitted  TxOutcome = "committed"
+	TxRolledBack TxOutcome = "rolled back"
+	TxPanicked   TxOutcome = "panicked" // rolled back because the callback panicked
+)
+
+// TxEvent describes an attempt to run a transaction with RunInTransaction or RunInTransactionWithRetry
+type TxEvent struct {
+	Attempt  int  // starting at 1
+	Retrying bool // whether the transaction is run again after this attempt
+	Outcome  TxOutcome
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// TxHook can be implemented by a Hook to be notified of each transaction attempt. The Outcome is empty
+// when the transaction could not begin.
+type TxHook interface {
+	AfterTx(ctx context.Context, e *TxEvent)
+}
+
+// WithHooks adds hooks that are called around every statement, see Hook
+func WithHooks(hooks ...Hook) ClientOption {
+	return func(c *Client) {
+		c.hooks = append(c.hooks, hooks...)
+	}
+}
+
+// observe runs the statement f between the client's hooks and wraps its error, see Error.
+// It is safe to call on a nil client.
+func (c *Client) observe(ctx context.Context, e *QueryEvent, f func(context.Context) error) error {
+	var hooks []Hook
+	if c != nil {
+		hooks = c.hooks
+	}
+
+	if len(hooks) == 0 {
+		return wrapError(f(ctx))
+	}
+
+	ctxs := make([]context.Context, len(hooks))
+	for i, h := range hooks {
+		ctx = h.BeforeQuery(ctx, e)
+		ctxs[i] = ctx
+	}
+
+	e.Start = time.Now()
+	e.Err = wrapError(f(ctx))
+	e.Duration = time.Since(e.Start)
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i].AfterQuery(ctxs[i], e)
+	}
+
+	return e.Err
+}
+
+// afterTx passes e to the client's hooks implementing TxHook
+func (c *Client) afterTx(ctx context.Context, e *TxEvent) {
+	for _, h := range c.hooks {
+		if th, ok := h.(TxHook); ok {
+			th.AfterTx(ctx, e)
+		}
+	}
+}
+
+// queryInfo describes the statement built by a Query so the hooks know the operation and table
+type queryInfo struct {
+	op         Operation
+	table      string
+	argColumns []string
+}
+
+type queryInfoKey struct{}
+
+func withQueryInfo(ctx context.Context, op Operation, table string) context.Context {
+	return context.WithValue(ctx, queryInfoKey{}, queryInfo{op: op, table: table})
+}
+
+// withArgColumns adds the column each argument of the statement is bound to
+func withArgColumns(ctx context.Context, cols []string) context.Context {
+	info, _ := ctx.Value(queryInfoKey{}).(queryInfo)
+	info.argColumns = cols
+	return context.WithValue(ctx, queryInfoKey{}, info)
+}
+
+func newQueryEvent(ctx context.Context, query string, args []interface{}) *QueryEvent {
+	e := &QueryEvent{SQL: query, Args: args, RowsAffected: -1}
+
+	info, _ := ctx.Value(queryInfoKey{}).(queryInfo)
+	e.Op, e.Table, e.ArgColumns = info.op, info.table, info.argColumns
+	if e.Op == "" {
+		e.Op = inferOperation(query)
+	}
+
+	return e
+}
+
+// inferOperation returns the operation from the first keyword of a raw query
+func inferOperation(query string) Operation {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return OpExec
+	}
+
+	switch op := Operation(strings.ToLower(fields[0])); op {
+	case OpSelect, OpInsert, OpUpdate, OpDelete, OpCopy:
+		return op
+	default:
+		return OpExec
+	}
+}
@@ -0,0 +1,126 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingHook struct {
+	name   string
+	calls  *[]string
+	events []QueryEvent
+}
+
+type hookNameKey struct{}
+
+func (h *recordingHook) BeforeQuery(ctx context.Context, e *QueryEvent) context.Context {
+	*h.calls = append(*h.calls, "before "+h.name)
+	return context.WithValue(ctx, hookNameKey{}, h.name)
+}
+
+func (h *recordingHook) AfterQuery(ctx context.Context, e *QueryEvent) {
+	*h.calls = append(*h.calls, "after "+h.name+" "+ctx.Value(hookNameKey{}).(string))
+	h.events = append(h.events, *e)
+}
+
+func TestClient_Observe(t *testing.T) {
+	var calls []string
+	first := &recordingHook{name: "first", calls: &calls}
+	second := &recordingHook{name: "second", calls: &calls}
+
+	c := NewClient(nil, WithHooks(first, second))
+
+	someErr := errors.New("some error")
+	e := newQueryEvent(context.Background(), "DELETE FROM t", nil)
+	err := c.observe(context.Background(), e, func(ctx context.Context) error {
+		calls = append(calls, "run "+ctx.Value(hookNameKey{}).(string))
+		return someErr
+	})
+
+	require.Equal(t, someErr, err)
+	require.Equal(t, []string{"before first", "before second", "run second", "after second second", "after first first"}, calls)
+	require.Len(t, first.events, 1)
+	require.Equal(t, OpDelete, first.events[0].Op)
+	require.Equal(t, someErr, first.events[0].Err)
+	require.False(t, first.events[0].Start.IsZero())
+
+	// nil clients run the statement without hooks
+	var nilClient *Client
+	require.Nil(t, nilClient.observe(context.Background(), e, func(context.Context) error { return nil }))
+}
+
+func TestNewQueryEvent(t *testing.T) {
+	e := newQueryEvent(withQueryInfo(context.Background(), OpUpdate, "mock_models"), "UPDATE ...", nil)
+	require.Equal(t, OpUpdate, e.Op)
+	require.Equal(t, "mock_models", e.Table)
+	require.Equal(t, int64(-1), e.RowsAffected)
+
+	require.Equal(t, OpSelect, inferOperation("  select 1"))
+	require.Equal(t, OpCopy, inferOperation("COPY t FROM STDIN"))
+	require.Equal(t, OpExec, inferOperation("create table t ()"))
+	require.Equal(t, OpExec, inferOperation(""))
+}
+
+func TestClient_Hooks(t *testing.T) {
+	var calls []string
+	h := &recordingHook{name: "hook", calls: &calls}
+
+	c := NewClient(nil, WithHooks(h))
+
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+
+	if _, err := c.Exec(modelsTable); err != nil {
+		t.Fatalf("failed to create table %v", err)
+	}
+
+	defer func() {
+		_, _ = c.Exec("drop table mock_models")
+		_ = c.Close()
+	}()
+
+	ctx := context.Background()
+	h.events = nil
+
+	m := &MockModel{IntField: 1}
+	require.Nil(t, c.Insert(ctx, m))
+
+	err := c.RunInTransaction(ctx, func(ctx context.Context, tx *Tx) error {
+		_, err := tx.UpdateAll(m.TableName(), Attrs{"int_field": 2}).Exec(ctx)
+		return err
+	}, nil)
+	require.Nil(t, err)
+
+	require.Nil(t, c.BulkInsert(NewSliceModelProvider([]Model{&MockModel{IntField: 3}, &MockModel{IntField: 4}})))
+
+	require.Len(t, h.events, 3)
+	require.Equal(t, OpInsert, h.events[0].Op)
+	require.Equal(t, "mock_models", h.events[0].Table)
+	require.Equal(t, OpUpdate, h.events[1].Op)
+	require.Equal(t, int64(1), h.events[1].RowsAffected)
+	require.Equal(t, OpCopy, h.events[2].Op)
+	require.Equal(t, int64(2), h.events[2].RowsAffected)
+
+	// single row queries and statement preparation are observed too
+	h.events = nil
+	var n int
+	require.Nil(t, c.QueryRowContext(ctx, "SELECT count(*) FROM mock_models").Scan(&n))
+
+	stmt, err := c.PrepareContext(ctx, "SELECT count(*) FROM mock_models")
+	require.Nil(t, err)
+	require.Nil(t, stmt.Close())
+
+	err = c.RunInTransaction(ctx, func(ctx context.Context, tx *Tx) error {
+		return tx.QueryRowContext(ctx, "SELECT count(*) FROM mock_models").Scan(&n)
+	}, nil)
+	require.Nil(t, err)
+
+	require.Len(t, h.events, 3)
+	for _, e := range h.events {
+		require.Equal(t, OpSelect, e.Op)
+	}
+}
@@ -0,0 +1,194 @@
+package psql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Identifier is a table reference that can be schema qualified and aliased.
+//
+// Its String form can be passed anywhere a table name is accepted:
+//
+//	c.Select(Identifier{Schema: "reporting", Name: "flights", Alias: "f"}.String())
+type Identifier struct {
+	Schema string
+	Name   string
+	Alias  string
+}
+
+// SchemaNamer can be implemented by a Model whose table lives outside of the connection's search_path.
+type SchemaNamer interface {
+	SchemaName() string
+}
+
+// ParseIdentifier parses table references such as `flights`, `reporting.flights`,
+// `reporting.flights AS f` or `"Reporting"."Flights" AS "f"`. Double quoted parts are
+// taken literally so names containing dots can still be expressed. Aliases require AS so that unquoted
+// names with spaces are rejected instead of read as aliases, QuoteTable quotes those whole.
+func ParseIdentifier(s string) (Identifier, error) {
+	var id Identifier
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return id, errors.New("empty identifier")
+	}
+
+	var parts []string
+	rest := s
+	for {
+		part, n, err := scanIdentifierPart(rest)
+		if err != nil {
+			return id, fmt.Errorf("invalid identifier %q: %w", s, err)
+		}
+
+		parts = append(parts, part)
+		rest = rest[n:]
+
+		if !strings.HasPrefix(rest, ".") {
+			break
+		}
+		rest = rest[1:]
+	}
+
+	if len(parts) > 2 {
+		return id, fmt.Errorf("invalid identifier %q: too many parts", s)
+	}
+
+	if len(parts) == 2 {
+		id.Schema, id.Name = parts[0], parts[1]
+	} else {
+		id.Name = parts[0]
+	}
+
+	if rest == "" {
+		return id, nil
+	}
+
+	if !unicode.IsSpace(rune(rest[0])) {
+		return id, fmt.Errorf("invalid identifier %q: unexpected %q", s, rest)
+	}
+
+	rest = strings.TrimSpace(rest)
+	if len(rest) < 3 || !strings.EqualFold(rest[:2], "as") || !unicode.IsSpace(rune(rest[2])) {
+		return id, fmt.Errorf("invalid identifier %q: unexpected %q, quote names containing spaces", s, rest)
+	}
+	rest = strings.TrimSpace(rest[3:])
+
+	alias, n, err := scanIdentifierPart(rest)
+	if err != nil {
+		return id, fmt.Errorf("invalid alias in %q: %w", s, err)
+	}
+
+	if rest[n:] != "" {
+		return id, fmt.Errorf("invalid identifier %q: unexpected %q", s, rest[n:])
+	}
+
+	id.Alias = alias
+
+	return id, nil
+}
+
+// String returns the identifier quoted part by part
+func (id Identifier) String() string {
+	var b StringsBuilder
+	if id.Schema != "" {
+		b.WriteStrings(Quote(id.Schema), ".")
+	}
+
+	b.WriteString(Quote(id.Name))
+
+	if id.Alias != "" {
+		b.WriteStrings(" AS ", Quote(id.Alias))
+	}
+
+	return b.String()
+}
+
+// QuoteTable quotes a table reference part by part, see ParseIdentifier for the accepted forms.
+// Anything that cannot be parsed, e.g. `my table`, is quoted as a single identifier as before.
+func QuoteTable(str string) string {
+	id, err := ParseIdentifier(str)
+	if err != nil {
+		return Quote(str)
+	}
+
+	return id.String()
+}
+
+// modelTableName returns the table reference of a Model including the schema from SchemaNamer
+func modelTableName(m Model) string {
+	name := m.TableName()
+
+	sn, ok := m.(SchemaNamer)
+	if !ok || sn.SchemaName() == "" {
+		return name
+	}
+
+	id, err := ParseIdentifier(name)
+	if err != nil {
+		id = Identifier{Name: name}
+	}
+
+	if id.Schema == "" {
+		id.Schema = sn.SchemaName()
+	}
+
+	return id.String()
+}
+
+// modelIdentifier returns the schema and table name of a model, used where aliases are not allowed (COPY)
+func modelIdentifier(m Model) Identifier {
+	id, err := ParseIdentifier(modelTableName(m))
+	if err != nil {
+		return Identifier{Name: m.TableName()}
+	}
+
+	return id
+}
+
+// scanIdentifierPart reads one bare or double quoted identifier and returns it with the number of bytes consumed
+func scanIdentifierPart(s string) (string, int, error) {
+	if s == "" {
+		return "", 0, errors.New("missing name")
+	}
+
+	if s[0] != '"' {
+		n := strings.IndexFunc(s, func(r rune) bool {
+			return r == '.' || r == '"' || unicode.IsSpace(r)
+		})
+		if n == -1 {
+			n = len(s)
+		}
+
+		if n == 0 {
+			return "", 0, errors.New("missing name")
+		}
+
+		return s[:n], n, nil
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] != '"' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		// doubled quotes are an escaped quote
+		if i+1 < len(s) && s[i+1] == '"' {
+			b.WriteByte('"')
+			i++
+			continue
+		}
+
+		if b.Len() == 0 {
+			return "", 0, errors.New("empty quoted name")
+		}
+
+		return b.String(), i + 1, nil
+	}
+
+	return "", 0, errors.New("unterminated quoted name")
+}
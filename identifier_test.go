@@ -0,0 +1,136 @@
+package psql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type SchemaModel struct {
+	ID int `sql:"id"`
+}
+
+func (SchemaModel) TableName() string {
+	return "flights"
+}
+
+func (SchemaModel) SchemaName() string {
+	return "reporting"
+}
+
+func TestParseIdentifier(t *testing.T) {
+	tcs := map[string]struct {
+		Input    string
+		Expected Identifier
+		Quoted   string
+		Error    bool
+	}{
+		"table": {
+			Input:    "flights",
+			Expected: Identifier{Name: "flights"},
+			Quoted:   `"flights"`,
+		},
+		"schema and table": {
+			Input:    "reporting.flights",
+			Expected: Identifier{Schema: "reporting", Name: "flights"},
+			Quoted:   `"reporting"."flights"`,
+		},
+		"alias": {
+			Input:    "reporting.flights AS f",
+			Expected: Identifier{Schema: "reporting", Name: "flights", Alias: "f"},
+			Quoted:   `"reporting"."flights" AS "f"`,
+		},
+		"alias with as": {
+			Input:    "flights AS f",
+			Expected: Identifier{Name: "flights", Alias: "f"},
+			Quoted:   `"flights" AS "f"`,
+		},
+		"quoted parts": {
+			Input:    `"Report.ing"."fl""ights" as "F"`,
+			Expected: Identifier{Schema: "Report.ing", Name: `fl"ights`, Alias: "F"},
+			Quoted:   `"Report.ing"."fl""ights" AS "F"`,
+		},
+		"reserved word": {
+			Input:    "table",
+			Expected: Identifier{Name: "table"},
+			Quoted:   `"table"`,
+		},
+		"too many parts": {
+			Input: "a.b.c",
+			Error: true,
+		},
+		"unterminated quote": {
+			Input: `"flights`,
+			Error: true,
+		},
+		"alias without as": {
+			Input: "flights f",
+			Error: true,
+		},
+		"unquoted space": {
+			Input: "my table",
+			Error: true,
+		},
+		"quoted space": {
+			Input:    `"my table"`,
+			Expected: Identifier{Name: "my table"},
+			Quoted:   `"my table"`,
+		},
+		"unquoted quote": {
+			Input: `my"table`,
+			Error: true,
+		},
+		"trailing tokens": {
+			Input: "flights AS f g",
+			Error: true,
+		},
+		"empty": {
+			Input: "",
+			Error: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			id, err := ParseIdentifier(tc.Input)
+			if tc.Error {
+				require.NotNil(t, err)
+				return
+			}
+
+			require.Nil(t, err)
+			require.Equal(t, tc.Expected, id)
+			require.Equal(t, tc.Quoted, id.String())
+			require.Equal(t, tc.Quoted, QuoteTable(tc.Input))
+
+			// the quoted form parses back to the same identifier
+			reparsed, err := ParseIdentifier(id.String())
+			require.Nil(t, err)
+			require.Equal(t, id, reparsed)
+		})
+	}
+}
+
+func TestQuoteTable_Invalid(t *testing.T) {
+	require.Equal(t, `"a.b.c"`, QuoteTable("a.b.c"))
+}
+
+func TestModelTableName(t *testing.T) {
+	require.Equal(t, "mock_models", modelTableName(MockModel{}))
+	require.Equal(t, `"reporting"."flights"`, modelTableName(SchemaModel{}))
+	require.Equal(t, Identifier{Schema: "reporting", Name: "flights"}, modelIdentifier(&SchemaModel{}))
+}
+
+func TestQueries_SchemaQualified(t *testing.T) {
+	require.Equal(t, `SELECT * FROM "reporting"."flights" AS "f" WHERE f.id = $1`, selectQuery("reporting.flights AS f", nil, "f.id = $1", nil, 0))
+	require.Equal(t, `DELETE FROM "reporting"."flights"`, deleteQuery("reporting.flights", "", nil))
+	require.Equal(t, `UPDATE "reporting"."flights" SET ("a") = ROW($1)`, updateQuery("reporting.flights", []string{"a"}, "", nil))
+	require.Equal(t, `INSERT INTO "reporting"."flights" ("a") VALUES ($1) RETURNING "id"`, insertQuery("reporting.flights", []string{"a"}, nil))
+}
+
+func TestQueries_UnparsedTable(t *testing.T) {
+	// names that can't be parsed are quoted whole
+	require.Equal(t, `SELECT * FROM "my table"`, selectQuery("my table", nil, "", nil, 0))
+	require.Equal(t, `DELETE FROM "my""table"`, deleteQuery(`my"table`, "", nil))
+	require.Equal(t, `UPDATE "a.b.c" SET ("a") = ROW($1)`, updateQuery("a.b.c", []string{"a"}, "", nil))
+}
@@ -0,0 +1,86 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrIsolationLevel is returned by Tx.RequireIsolation when the transaction's isolation level is too low
+var ErrIsolationLevel = errors.New("insufficient transaction isolation level")
+
+// RunInReadOnlyTransaction runs f in a READ ONLY transaction, statements writing to tables fail with
+// ErrReadOnlyTransaction
+func (c *Client) RunInReadOnlyTransaction(ctx context.Context, f func(context.Context, *Tx) error) error {
+	return c.RunInTransaction(ctx, f, &sql.TxOptions{ReadOnly: true})
+}
+
+// RunInReadOnlySnapshot runs f in a SERIALIZABLE READ ONLY DEFERRABLE transaction. Beginning it may block
+// until a snapshot is available that cannot be affected by concurrent transactions, after which f runs
+// without the overhead or risk of serialization failures. It suits long running consistent reports.
+func (c *Client) RunInReadOnlySnapshot(ctx context.Context, f func(context.Context, *Tx) error) error {
+	o := c.txOptions(&sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true})
+	o.deferrable = true
+
+	e := &TxEvent{Attempt: 1}
+	err := c.runTransaction(ctx, f, o, e)
+	c.afterTx(ctx, e)
+
+	return err
+}
+
+// RunSerializable runs f in a SERIALIZABLE transaction. It fails with ErrSerializationFailure when
+// concurrent transactions conflict, see RunInTransactionWithRetry to run it again.
+func (c *Client) RunSerializable(ctx context.Context, f func(context.Context, *Tx) error) error {
+	return c.RunInTransaction(ctx, f, &sql.TxOptions{Isolation: sql.LevelSerializable})
+}
+
+// RunRepeatableRead runs f in a REPEATABLE READ transaction, all its statements see the same snapshot
+func (c *Client) RunRepeatableRead(ctx context.Context, f func(context.Context, *Tx) error) error {
+	return c.RunInTransaction(ctx, f, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+}
+
+// Isolation returns the isolation level the transaction was begun with, sql.LevelDefault when it uses
+// the server's default_transaction_isolation
+func (tx *Tx) Isolation() sql.IsolationLevel {
+	return tx.isolation
+}
+
+// ReadOnly reports whether the transaction was begun READ ONLY
+func (tx *Tx) ReadOnly() bool {
+	return tx.readOnly
+}
+
+// Deferrable reports whether the transaction was begun DEFERRABLE, see RunInReadOnlySnapshot
+func (tx *Tx) Deferrable() bool {
+	return tx.deferrable
+}
+
+// RequireIsolation returns an error wrapping ErrIsolationLevel if the transaction's isolation level is
+// lower than level. sql.LevelDefault and sql.LevelReadUncommitted count as sql.LevelReadCommitted
+// which is what postgres runs them as unless default_transaction_isolation is changed.
+func (tx *Tx) RequireIsolation(level sql.IsolationLevel) error {
+	if pgIsolation(tx.isolation) < pgIsolation(level) {
+		return fmt.Errorf("%w: %v required, transaction is %v", ErrIsolationLevel, pgIsolation(level), pgIsolation(tx.isolation))
+	}
+
+	return nil
+}
+
+// RequireWritable returns ErrReadOnlyTransaction if the transaction is READ ONLY
+func (tx *Tx) RequireWritable() error {
+	if tx.readOnly {
+		return ErrReadOnlyTransaction
+	}
+
+	return nil
+}
+
+func pgIsolation(level sql.IsolationLevel) sql.IsolationLevel {
+	if level == sql.LevelDefault || level == sql.LevelReadUncommitted {
+		return sql.LevelReadCommitted
+	}
+
+	return level
+}
@@ -0,0 +1,83 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTx_Require(t *testing.T) {
+	tx := &Tx{}
+	require.Nil(t, tx.RequireIsolation(sql.LevelReadCommitted))
+	require.Nil(t, tx.RequireIsolation(sql.LevelReadUncommitted))
+	require.True(t, errors.Is(tx.RequireIsolation(sql.LevelRepeatableRead), ErrIsolationLevel))
+	require.Nil(t, tx.RequireWritable())
+
+	tx = &Tx{isolation: sql.LevelSerializable, readOnly: true}
+	require.Nil(t, tx.RequireIsolation(sql.LevelRepeatableRead))
+	require.Nil(t, tx.RequireIsolation(sql.LevelSerializable))
+	require.Equal(t, ErrReadOnlyTransaction, tx.RequireWritable())
+}
+
+func TestClient_IsolationHelpers(t *testing.T) {
+	c := NewClient(nil, WithStatementTimeout(time.Second))
+
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+
+	if _, err := c.Exec(modelsTable); err != nil {
+		t.Fatalf("failed to create table %v", err)
+	}
+
+	defer func() {
+		_, _ = c.Exec("drop table mock_models")
+		_ = c.Close()
+	}()
+
+	ctx := context.Background()
+
+	show := func(ctx context.Context, tx *Tx, name string) string {
+		var v string
+		r, err := tx.RawQuery(ctx, "SELECT current_setting($1)", name)
+		require.Nil(t, err)
+		require.Nil(t, r.Scan(ctx, &v))
+		return v
+	}
+
+	err := c.RunInReadOnlyTransaction(ctx, func(ctx context.Context, tx *Tx) error {
+		require.True(t, tx.ReadOnly())
+		require.Equal(t, "on", show(ctx, tx, "transaction_read_only"))
+		return tx.Insert(ctx, &MockModel{IntField: 1})
+	})
+	require.True(t, errors.Is(err, ErrReadOnlyTransaction), err)
+
+	err = c.RunInReadOnlySnapshot(ctx, func(ctx context.Context, tx *Tx) error {
+		require.True(t, tx.ReadOnly())
+		require.True(t, tx.Deferrable())
+		require.Equal(t, sql.LevelSerializable, tx.Isolation())
+		require.Equal(t, "on", show(ctx, tx, "transaction_deferrable"))
+		require.Equal(t, "serializable", show(ctx, tx, "transaction_isolation"))
+		require.Equal(t, "1s", show(ctx, tx, "statement_timeout"))
+		return nil
+	})
+	require.Nil(t, err)
+
+	err = c.RunSerializable(ctx, func(ctx context.Context, tx *Tx) error {
+		require.Nil(t, tx.RequireIsolation(sql.LevelSerializable))
+		require.Equal(t, "serializable", show(ctx, tx, "transaction_isolation"))
+		return tx.Insert(ctx, &MockModel{IntField: 1})
+	})
+	require.Nil(t, err)
+
+	err = c.RunRepeatableRead(ctx, func(ctx context.Context, tx *Tx) error {
+		require.True(t, errors.Is(tx.RequireIsolation(sql.LevelSerializable), ErrIsolationLevel))
+		require.Equal(t, "repeatable read", show(ctx, tx, "transaction_isolation"))
+		return nil
+	})
+	require.Nil(t, err)
+}
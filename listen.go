@@ -0,0 +1,156 @@
+package psql
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Settings of the connections of subscriptions, see Client.Listen
+var (
+	ListenMinReconnectInterval = time.Second
+	ListenMaxReconnectInterval = time.Minute
+	ListenPingInterval         = 90 * time.Second // without notifications the connection is pinged to detect it broke
+)
+
+// Notification is a notification received by a Subscription
+type Notification struct {
+	Channel string
+	Payload string
+	PID     int // of the notifying server process
+
+	// Gap is set on the notification sent after the connection was re-established instead of a received
+	// notification, notifications sent while disconnected are lost so state derived from them should be
+	// reloaded
+	Gap bool
+}
+
+// Decode unmarshals the JSON payload into v, e.g. a struct or a *JSONObject
+func (n *Notification) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(n.Payload), v)
+}
+
+// Subscription delivers the notifications of the channels it listens on, see Client.Listen
+type Subscription struct {
+	C <-chan *Notification // closed once the subscription ended
+
+	listener *pq.Listener
+	work     *work
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// Listen returns a subscription to the given channels on its own connection, which is re-established
+// and listens again on the channels after it broke, see Notification.Gap. ctx only bounds waiting for the
+// server to acknowledge listening on the channels, the subscription runs until it is closed or the client
+// shuts down.
+func (c *Client) Listen(ctx context.Context, channels ...string) (*Subscription, error) {
+	if c.work.isClosing() {
+		return nil, ErrClientShutdown
+	}
+
+	l := pq.NewListener(c.connStr, ListenMinReconnectInterval, ListenMaxReconnectInterval, nil)
+
+	out := make(chan *Notification)
+	subCtx, cancel := context.WithCancel(context.Background())
+	s := &Subscription{C: out, listener: l, work: c.work, cancel: cancel, done: make(chan struct{})}
+
+	if err := c.work.track(s); err != nil {
+		cancel()
+		_ = l.Close()
+		return nil, err
+	}
+
+	go s.run(subCtx, out)
+
+	for _, channel := range channels {
+		if err := s.Listen(ctx, channel); err != nil {
+			_ = s.Close()
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (s *Subscription) run(ctx context.Context, out chan<- *Notification) {
+	defer close(s.done)
+	defer close(out)
+	defer s.listener.Close()
+
+	t := time.NewTicker(ListenPingInterval)
+	defer t.Stop()
+
+	for {
+		var n *Notification
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			go func() {
+				_ = s.listener.Ping()
+			}()
+			continue
+		case pqN := <-s.listener.Notify:
+			if pqN == nil {
+				n = &Notification{Gap: true}
+			} else {
+				n = &Notification{Channel: pqN.Channel, Payload: pqN.Extra, PID: pqN.BePid}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case out <- n:
+		}
+	}
+}
+
+// Listen adds a channel to the subscription
+func (s *Subscription) Listen(ctx context.Context, channel string) error {
+	return s.call(ctx, func() error {
+		return s.listener.Listen(channel)
+	})
+}
+
+// Unlisten removes a channel from the subscription
+func (s *Subscription) Unlisten(ctx context.Context, channel string) error {
+	return s.call(ctx, func() error {
+		return s.listener.Unlisten(channel)
+	})
+}
+
+// call runs f until it returns or ctx is done, the listener's calls block while it is disconnected
+func (s *Subscription) call(ctx context.Context, f func() error) error {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- f()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close ends the subscription and closes its connection, Shutdown closes the subscriptions of the client
+func (s *Subscription) Close() error {
+	s.cancel()
+	<-s.done
+	s.work.untrack(s)
+
+	return nil
+}
+
+// Notify sends a notification on channel through pg_notify, it is delivered to listeners only if the
+// transaction commits
+func (tx *Tx) Notify(ctx context.Context, channel, payload string) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, payload)
+	return err
+}
@@ -0,0 +1,108 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotification_Decode(t *testing.T) {
+	n := &Notification{Channel: "flights", Payload: `{"id": 7, "status": "landed"}`}
+
+	var flight struct {
+		ID     int    `json:"id"`
+		Status string `json:"status"`
+	}
+	require.Nil(t, n.Decode(&flight))
+	require.Equal(t, 7, flight.ID)
+	require.Equal(t, "landed", flight.Status)
+
+	var o JSONObject
+	require.Nil(t, n.Decode(&o))
+	status, err := o.String("status")
+	require.Nil(t, err)
+	require.Equal(t, "landed", status)
+
+	require.NotNil(t, (&Notification{Payload: "landed"}).Decode(&o))
+}
+
+func TestClient_ListenUnreachable(t *testing.T) {
+	c := NewClient(&Config{Host: "127.0.0.1", Port: "1", SSLMode: "disable"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := c.Listen(ctx, "flights")
+	require.Equal(t, context.DeadlineExceeded, err)
+
+	require.Nil(t, c.Shutdown(context.Background()))
+	_, err = c.Listen(context.Background(), "flights")
+	require.Equal(t, ErrClientShutdown, err)
+}
+
+func TestClient_Listen(t *testing.T) {
+	c := NewClient(nil)
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := c.Listen(ctx, "flights")
+	require.Nil(t, err)
+	defer s.Close()
+
+	// notifications are only sent when the transaction commits
+	someErr := errors.New("some error")
+	err = c.RunInTransaction(ctx, func(ctx context.Context, tx *Tx) error {
+		require.Nil(t, tx.Notify(ctx, "flights", `{"status": "canceled"}`))
+		return someErr
+	}, nil)
+	require.Equal(t, someErr, err)
+
+	err = c.RunInTransaction(ctx, func(ctx context.Context, tx *Tx) error {
+		return tx.Notify(ctx, "flights", `{"status": "landed"}`)
+	}, nil)
+	require.Nil(t, err)
+
+	n := <-s.C
+	require.Equal(t, "flights", n.Channel)
+	require.False(t, n.Gap)
+	require.NotZero(t, n.PID)
+
+	var o JSONObject
+	require.Nil(t, n.Decode(&o))
+	require.Equal(t, JSONObject{"status": "landed"}, o)
+
+	require.Nil(t, s.Unlisten(ctx, "flights"))
+	require.Nil(t, s.Listen(ctx, "gates"))
+
+	_, err = c.Exec("NOTIFY gates, 'A1'")
+	require.Nil(t, err)
+	require.Equal(t, "A1", (<-s.C).Payload)
+
+	require.Nil(t, s.Close())
+	_, ok := <-s.C
+	require.False(t, ok)
+
+	// the subscription outlives the context of the handshake
+	listenCtx, listenCancel := context.WithCancel(ctx)
+	s, err = c.Listen(listenCtx, "gates")
+	require.Nil(t, err)
+	defer s.Close()
+	listenCancel()
+
+	_, err = c.Exec("NOTIFY gates, 'B2'")
+	require.Nil(t, err)
+	require.Equal(t, "B2", (<-s.C).Payload)
+
+	// Shutdown ends the subscriptions
+	require.Nil(t, c.Shutdown(ctx))
+	_, ok = <-s.C
+	require.False(t, ok)
+}
@@ -0,0 +1,345 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DefaultLatencyBuckets are the upper bounds in seconds of the latency histograms of InMemoryMetrics
+var DefaultLatencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// DefaultPoolSampleInterval is how often pool stats are sampled when no interval is given to WithMetrics
+var DefaultPoolSampleInterval = 15 * time.Second
+
+// QueryMetric is the measurement of a single statement
+type QueryMetric struct {
+	Op       Operation
+	Table    string // empty for raw queries
+	Duration time.Duration
+
+	// ErrorClass is the SQLSTATE class (first 2 characters of the code) of a postgres error,
+	// "other" for errors that do not come from postgres and empty on success
+	ErrorClass string
+}
+
+// MetricsExporter receives the measurements of a MetricsCollector, it must be safe for concurrent use
+type MetricsExporter interface {
+	ObserveQuery(QueryMetric)
+	ObservePool(sql.DBStats)
+}
+
+// MetricsCollector is a Hook that passes each statement to an exporter and periodically samples the
+// connection pool stats, see WithMetrics
+type MetricsCollector struct {
+	exporter MetricsExporter
+	interval time.Duration
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewMetricsCollector returns a collector sampling pool stats every interval once started
+func NewMetricsCollector(exporter MetricsExporter, interval time.Duration) *MetricsCollector {
+	if interval <= 0 {
+		interval = DefaultPoolSampleInterval
+	}
+
+	return &MetricsCollector{exporter: exporter, interval: interval}
+}
+
+// WithMetrics records query metrics and connection pool stats into the exporter. Pool stats are sampled
+// every interval (DefaultPoolSampleInterval if 0) between Client.Start and Client.Stop.
+func WithMetrics(exporter MetricsExporter, interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.metrics = NewMetricsCollector(exporter, interval)
+		c.hooks = append(c.hooks, c.metrics)
+	}
+}
+
+func (m *MetricsCollector) BeforeQuery(ctx context.Context, e *QueryEvent) context.Context {
+	return ctx
+}
+
+func (m *MetricsCollector) AfterQuery(ctx context.Context, e *QueryEvent) {
+	m.exporter.ObserveQuery(QueryMetric{
+		Op:         e.Op,
+		Table:      e.Table,
+		Duration:   e.Duration,
+		ErrorClass: errorClass(e.Err),
+	})
+}
+
+// Start samples the pool stats of db until Stop is called
+func (m *MetricsCollector) Start(db *sql.DB) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stop != nil {
+		return
+	}
+
+	m.stop, m.done = make(chan struct{}), make(chan struct{})
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+
+		t := time.NewTicker(m.interval)
+		defer t.Stop()
+
+		m.exporter.ObservePool(db.Stats())
+		for {
+			select {
+			case <-t.C:
+				m.exporter.ObservePool(db.Stats())
+			case <-stop:
+				return
+			}
+		}
+	}(m.stop, m.done)
+}
+
+// Stop stops sampling and waits for the sampler to exit
+func (m *MetricsCollector) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stop == nil {
+		return
+	}
+
+	close(m.stop)
+	<-m.done
+	m.stop, m.done = nil, nil
+}
+
+func errorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && len(pqErr.Code) >= 2 {
+		return string(pqErr.Code[:2])
+	}
+
+	return "other"
+}
+
+// InMemoryMetrics is a MetricsExporter that keeps latency histograms per operation and table, error
+// counts per SQLSTATE class and the latest pool stats. It serves them in the prometheus text format
+// as an http.Handler.
+type InMemoryMetrics struct {
+	buckets []float64
+
+	mu         sync.Mutex
+	operations map[string]*Histogram
+	tables     map[string]*Histogram
+	errors     map[string]int64
+	pool       sql.DBStats
+}
+
+// Histogram counts observations in seconds, Counts[i] is the number of observations <= Buckets[i]
+type Histogram struct {
+	Buckets []float64
+	Counts  []uint64
+	Count   uint64
+	Sum     float64
+}
+
+func (h *Histogram) observe(v float64) {
+	for i, b := range h.Buckets {
+		if v <= b {
+			h.Counts[i]++
+		}
+	}
+	h.Count++
+	h.Sum += v
+}
+
+func (h *Histogram) copy() Histogram {
+	c := *h
+	c.Counts = append([]uint64(nil), h.Counts...)
+	return c
+}
+
+// MetricsSnapshot is a copy of the metrics held by InMemoryMetrics
+type MetricsSnapshot struct {
+	Operations map[Operation]Histogram
+	Tables     map[string]Histogram
+	Errors     map[string]int64 // by SQLSTATE class
+	Pool       sql.DBStats
+}
+
+// NewInMemoryMetrics returns metrics using the given histogram buckets in seconds, DefaultLatencyBuckets if none
+func NewInMemoryMetrics(buckets ...float64) *InMemoryMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &InMemoryMetrics{
+		buckets:    buckets,
+		operations: make(map[string]*Histogram),
+		tables:     make(map[string]*Histogram),
+		errors:     make(map[string]int64),
+	}
+}
+
+func (m *InMemoryMetrics) ObserveQuery(q QueryMetric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seconds := q.Duration.Seconds()
+
+	m.histogram(m.operations, string(q.Op)).observe(seconds)
+	if q.Table != "" {
+		m.histogram(m.tables, q.Table).observe(seconds)
+	}
+
+	if q.ErrorClass != "" {
+		m.errors[q.ErrorClass]++
+	}
+}
+
+func (m *InMemoryMetrics) ObservePool(stats sql.DBStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pool = stats
+}
+
+// histogram returns the histogram for key creating it if needed, m.mu must be held
+func (m *InMemoryMetrics) histogram(hs map[string]*Histogram, key string) *Histogram {
+	h, ok := hs[key]
+	if !ok {
+		h = &Histogram{Buckets: m.buckets, Counts: make([]uint64, len(m.buckets))}
+		hs[key] = h
+	}
+
+	return h
+}
+
+// Snapshot returns a copy of the current metrics
+func (m *InMemoryMetrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := MetricsSnapshot{
+		Operations: make(map[Operation]Histogram, len(m.operations)),
+		Tables:     make(map[string]Histogram, len(m.tables)),
+		Errors:     make(map[string]int64, len(m.errors)),
+		Pool:       m.pool,
+	}
+
+	for op, h := range m.operations {
+		s.Operations[Operation(op)] = h.copy()
+	}
+	for table, h := range m.tables {
+		s.Tables[table] = h.copy()
+	}
+	for class, n := range m.errors {
+		s.Errors[class] = n
+	}
+
+	return s
+}
+
+// ServeHTTP writes the metrics in the prometheus text exposition format
+func (m *InMemoryMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.WritePrometheus(w)
+}
+
+// WritePrometheus writes the metrics in the prometheus text exposition format
+func (m *InMemoryMetrics) WritePrometheus(w io.Writer) error {
+	s := m.Snapshot()
+
+	var b StringsBuilder
+
+	ops := make([]string, 0, len(s.Operations))
+	for op := range s.Operations {
+		ops = append(ops, string(op))
+	}
+	sort.Strings(ops)
+
+	writeHeader(&b, "psql_query_duration_seconds", "Latency of statements by operation.", "histogram")
+	for _, op := range ops {
+		writeHistogram(&b, "psql_query_duration_seconds", "operation", op, s.Operations[Operation(op)])
+	}
+
+	tables := make([]string, 0, len(s.Tables))
+	for table := range s.Tables {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	writeHeader(&b, "psql_table_query_duration_seconds", "Latency of statements by table.", "histogram")
+	for _, table := range tables {
+		writeHistogram(&b, "psql_table_query_duration_seconds", "table", table, s.Tables[table])
+	}
+
+	classes := make([]string, 0, len(s.Errors))
+	for class := range s.Errors {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	writeHeader(&b, "psql_query_errors_total", "Failed statements by SQLSTATE class.", "counter")
+	for _, class := range classes {
+		b.WriteStrings("psql_query_errors_total{class=\"", escapeLabel(class), "\"} ", strconv.FormatInt(s.Errors[class], 10), "\n")
+	}
+
+	gauges := []struct {
+		name, help, typ string
+		value           float64
+	}{
+		{"psql_pool_max_open_connections", "Maximum number of open connections.", "gauge", float64(s.Pool.MaxOpenConnections)},
+		{"psql_pool_open_connections", "Number of established connections.", "gauge", float64(s.Pool.OpenConnections)},
+		{"psql_pool_in_use_connections", "Number of connections currently in use.", "gauge", float64(s.Pool.InUse)},
+		{"psql_pool_idle_connections", "Number of idle connections.", "gauge", float64(s.Pool.Idle)},
+		{"psql_pool_wait_count_total", "Number of connections waited for.", "counter", float64(s.Pool.WaitCount)},
+		{"psql_pool_wait_duration_seconds_total", "Time blocked waiting for a connection.", "counter", s.Pool.WaitDuration.Seconds()},
+	}
+	for _, g := range gauges {
+		writeHeader(&b, g.name, g.help, g.typ)
+		b.WriteStrings(g.name, " ", formatFloat(g.value), "\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeHeader(b *StringsBuilder, name, help, typ string) {
+	b.WriteStrings("# HELP ", name, " ", help, "\n# TYPE ", name, " ", typ, "\n")
+}
+
+func writeHistogram(b *StringsBuilder, name, label, value string, h Histogram) {
+	labels := label + "=\"" + escapeLabel(value) + "\""
+	for i, bucket := range h.Buckets {
+		b.WriteStrings(name, "_bucket{", labels, ",le=\"", formatFloat(bucket), "\"} ", strconv.FormatUint(h.Counts[i], 10), "\n")
+	}
+	b.WriteStrings(name, "_bucket{", labels, ",le=\"+Inf\"} ", strconv.FormatUint(h.Count, 10), "\n")
+	b.WriteStrings(name, "_sum{", labels, "} ", formatFloat(h.Sum), "\n")
+	b.WriteStrings(name, "_count{", labels, "} ", strconv.FormatUint(h.Count, 10), "\n")
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
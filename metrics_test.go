@@ -0,0 +1,107 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+type poolRecorder struct {
+	*InMemoryMetrics
+	samples chan sql.DBStats
+}
+
+func (r poolRecorder) ObservePool(stats sql.DBStats) {
+	r.InMemoryMetrics.ObservePool(stats)
+	select {
+	case r.samples <- stats:
+	default:
+	}
+}
+
+func TestMetricsCollector(t *testing.T) {
+	m := NewInMemoryMetrics(0.01, 0.1)
+	mc := NewMetricsCollector(m, time.Hour)
+
+	events := []*QueryEvent{
+		{Op: OpSelect, Table: "mock_models", Duration: 5 * time.Millisecond},
+		{Op: OpSelect, Table: "mock_models", Duration: 50 * time.Millisecond},
+		{Op: OpInsert, Table: "mock_models", Duration: time.Second, Err: &pq.Error{Code: "23505"}},
+		{Op: OpExec, Duration: time.Millisecond, Err: errors.New("some error")},
+	}
+	for _, e := range events {
+		mc.AfterQuery(context.Background(), e)
+	}
+
+	s := m.Snapshot()
+	require.Equal(t, []uint64{1, 2}, s.Operations[OpSelect].Counts)
+	require.Equal(t, uint64(2), s.Operations[OpSelect].Count)
+	require.Equal(t, []uint64{0, 0}, s.Operations[OpInsert].Counts)
+	require.Equal(t, uint64(3), s.Tables["mock_models"].Count)
+	require.NotContains(t, s.Tables, "")
+	require.Equal(t, map[string]int64{"23": 1, "other": 1}, s.Errors)
+
+	// snapshots are copies
+	s.Operations[OpSelect].Counts[0] = 10
+	require.Equal(t, uint64(1), m.Snapshot().Operations[OpSelect].Counts[0])
+}
+
+func TestMetricsCollector_Pool(t *testing.T) {
+	db, err := sql.Open("postgres", "")
+	require.Nil(t, err)
+	defer db.Close()
+
+	db.SetMaxOpenConns(7)
+
+	r := poolRecorder{InMemoryMetrics: NewInMemoryMetrics(), samples: make(chan sql.DBStats, 10)}
+	mc := NewMetricsCollector(r, time.Millisecond)
+
+	mc.Start(db)
+	<-r.samples
+	<-r.samples
+	mc.Stop()
+
+	require.Equal(t, 7, r.Snapshot().Pool.MaxOpenConnections)
+
+	// stopping twice is safe
+	mc.Stop()
+}
+
+func TestInMemoryMetrics_Prometheus(t *testing.T) {
+	m := NewInMemoryMetrics(0.1)
+	m.ObserveQuery(QueryMetric{Op: OpSelect, Table: `we"ird`, Duration: 50 * time.Millisecond})
+	m.ObserveQuery(QueryMetric{Op: OpDelete, Duration: time.Second, ErrorClass: "40"})
+	m.ObservePool(sql.DBStats{OpenConnections: 3, InUse: 1, Idle: 2, WaitCount: 4, WaitDuration: 2 * time.Second})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	require.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain"))
+
+	body := w.Body.String()
+	expected := []string{
+		"# TYPE psql_query_duration_seconds histogram",
+		`psql_query_duration_seconds_bucket{operation="select",le="0.1"} 1`,
+		`psql_query_duration_seconds_bucket{operation="delete",le="0.1"} 0`,
+		`psql_query_duration_seconds_bucket{operation="delete",le="+Inf"} 1`,
+		`psql_query_duration_seconds_sum{operation="delete"} 1`,
+		`psql_query_duration_seconds_count{operation="select"} 1`,
+		`psql_table_query_duration_seconds_count{table="we\"ird"} 1`,
+		`psql_query_errors_total{class="40"} 1`,
+		"psql_pool_open_connections 3",
+		"psql_pool_in_use_connections 1",
+		"psql_pool_idle_connections 2",
+		"psql_pool_wait_count_total 4",
+		"psql_pool_wait_duration_seconds_total 2",
+	}
+	for _, line := range expected {
+		require.Contains(t, body, line+"\n")
+	}
+}
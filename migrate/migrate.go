@@ -0,0 +1,355 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	psql "github.com/airspacetechnologies/go-psql"
+)
+
+// DefaultTable records the applied migrations unless WithTable is given
+const DefaultTable = "schema_migrations"
+
+var (
+	ErrChecksumMismatch = errors.New("applied migration was changed")
+	ErrUnknownMigration = errors.New("applied migration is unknown")
+	ErrIrreversible     = errors.New("migration can't be rolled back")
+)
+
+// Option configures a Migrator
+type Option func(*Migrator)
+
+// WithTable records the applied migrations in table, which can be schema qualified
+func WithTable(table string) Option {
+	return func(m *Migrator) {
+		m.tableName = table
+	}
+}
+
+// WithDryRun makes Up and Down return the migrations they would run without running them
+func WithDryRun() Option {
+	return func(m *Migrator) {
+		m.dryRun = true
+	}
+}
+
+// WithStatementTimeout bounds the statements of the steps run in a transaction, they run without a
+// statement timeout by default whatever the client's default is. NoTransaction steps run with the
+// client's default, migrate with a client without one when they take longer.
+func WithStatementTimeout(d time.Duration) Option {
+	return func(m *Migrator) {
+		m.statementTimeout = d
+	}
+}
+
+// Migrator applies migrations to the database of a client. Runners of the same table wait for each other
+// through an advisory lock.
+type Migrator struct {
+	client           *psql.Client
+	migrations       []*Migration
+	tableName        string
+	table            string // quoted
+	dryRun           bool
+	statementTimeout time.Duration // 0 disables it
+}
+
+// Status is the state of a migration, see Migrator.Status
+type Status struct {
+	Version   int64
+	Name      string
+	Migration *Migration // nil when the applied migration isn't known
+	Applied   bool
+	AppliedAt time.Time
+	Changed   bool // the migration changed since it was applied
+}
+
+type appliedMigration struct {
+	version   int64
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// New returns a migrator of the given migrations, e.g. read with ReadFS and Go migrations
+func New(c *psql.Client, migrations []*Migration, opts ...Option) (*Migrator, error) {
+	m := &Migrator{client: c, tableName: DefaultTable}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	id, err := psql.ParseIdentifier(m.tableName)
+	if err != nil || id.Alias != "" {
+		return nil, fmt.Errorf("invalid migrations table %q", m.tableName)
+	}
+	m.table = id.String()
+
+	m.migrations = append([]*Migration(nil), migrations...)
+	sortMigrations(m.migrations)
+
+	for i, mig := range m.migrations {
+		if mig.Up.empty() {
+			return nil, fmt.Errorf("migration %v has no up step", mig)
+		}
+
+		if i > 0 && m.migrations[i-1].Version == mig.Version {
+			return nil, fmt.Errorf("migrations %v and %v have the same version", m.migrations[i-1], mig)
+		}
+	}
+
+	return m, nil
+}
+
+// Up applies the migrations that weren't applied yet in order and returns them. It fails without applying
+// any when an applied migration changed, see Migration.Checksum.
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	var ran []*Migration
+	err := m.locked(ctx, func(ctx context.Context) error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		var pending []*Migration
+		for _, mig := range m.migrations {
+			a, ok := applied[mig.Version]
+			if !ok {
+				pending = append(pending, mig)
+				continue
+			}
+
+			if changed(mig, a) {
+				return fmt.Errorf("%w: %v", ErrChecksumMismatch, mig)
+			}
+		}
+
+		if m.dryRun {
+			ran = pending
+			return nil
+		}
+
+		for _, mig := range pending {
+			if err := m.run(ctx, mig, true); err != nil {
+				return err
+			}
+			ran = append(ran, mig)
+		}
+
+		return nil
+	})
+
+	return ran, err
+}
+
+// Down rolls back the applied migrations newer than version, newest first, and returns them. It fails
+// without rolling back any when one of them is unknown or can't be rolled back.
+func (m *Migrator) Down(ctx context.Context, version int64) ([]*Migration, error) {
+	var ran []*Migration
+	err := m.locked(ctx, func(ctx context.Context) error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		known := m.byVersion()
+
+		var rollback []*Migration
+		for _, a := range sortedApplied(applied) {
+			if a.version <= version {
+				continue
+			}
+
+			mig := known[a.version]
+			switch {
+			case mig == nil:
+				return fmt.Errorf("%w: %d_%v", ErrUnknownMigration, a.version, a.name)
+			case mig.Down.empty():
+				return fmt.Errorf("%w: %v", ErrIrreversible, mig)
+			}
+
+			rollback = append([]*Migration{mig}, rollback...)
+		}
+
+		if m.dryRun {
+			ran = rollback
+			return nil
+		}
+
+		for _, mig := range rollback {
+			if err := m.run(ctx, mig, false); err != nil {
+				return err
+			}
+			ran = append(ran, mig)
+		}
+
+		return nil
+	})
+
+	return ran, err
+}
+
+// Status returns the state of the known and applied migrations ordered by version
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name, Migration: mig}
+		if a, ok := applied[mig.Version]; ok {
+			s.Applied, s.AppliedAt, s.Changed = true, a.appliedAt, changed(mig, a)
+			delete(applied, mig.Version)
+		}
+		statuses = append(statuses, s)
+	}
+
+	for _, a := range applied {
+		statuses = append(statuses, Status{Version: a.version, Name: a.name, Applied: true, AppliedAt: a.appliedAt})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// locked runs f holding the migrations lock after creating the table, dry runs only read so they don't.
+// Migrations don't run in an ambient transaction of ctx.
+func (m *Migrator) locked(ctx context.Context, f func(context.Context) error) error {
+	ctx = psql.WithoutTx(ctx)
+	if m.dryRun {
+		return f(ctx)
+	}
+
+	return m.client.WithAdvisoryLock(ctx, psql.AdvisoryKeyFor("psql migrate "+m.table), func(ctx context.Context) error {
+		_, err := m.client.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+m.table+` (
+			version bigint PRIMARY KEY,
+			name text NOT NULL,
+			checksum text NOT NULL,
+			applied_at timestamptz NOT NULL DEFAULT now()
+		)`)
+		if err != nil {
+			return fmt.Errorf("unable to create the migrations table: %w", err)
+		}
+
+		return f(ctx)
+	})
+}
+
+// applied returns the applied migrations by version, none when the table doesn't exist yet
+func (m *Migrator) applied(ctx context.Context) (map[int64]appliedMigration, error) {
+	var exists bool
+	if err := m.client.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", m.table).Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]appliedMigration)
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := m.client.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM "+m.table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[a.version] = a
+	}
+
+	return applied, rows.Err()
+}
+
+// run runs a step of mig and records it in the same transaction unless the step opted out
+func (m *Migrator) run(ctx context.Context, mig *Migration, up bool) error {
+	step, direction := mig.Up, "up"
+	if !up {
+		step, direction = mig.Down, "down"
+	}
+
+	record := func(ctx context.Context, c psql.QueryClient) error {
+		var err error
+		if up {
+			_, err = c.ExecContext(ctx, "INSERT INTO "+m.table+" (version, name, checksum) VALUES ($1, $2, $3)",
+				mig.Version, mig.Name, mig.Checksum())
+		} else {
+			_, err = c.ExecContext(ctx, "DELETE FROM "+m.table+" WHERE version = $1", mig.Version)
+		}
+		return err
+	}
+
+	var err error
+	if step.NoTransaction {
+		ctx = psql.WithoutTx(ctx)
+		if err = step.run(ctx, m.client); err == nil {
+			err = record(ctx, m.client)
+		}
+	} else {
+		err = m.client.RunInTransaction(ctx, func(ctx context.Context, tx *psql.Tx) error {
+			// like SET LOCAL, the client's default doesn't apply to migrations
+			_, err := tx.ExecContext(ctx, "SELECT set_config('statement_timeout', $1, true)", m.statementTimeoutSetting())
+			if err != nil {
+				return err
+			}
+
+			if err := step.run(ctx, tx); err != nil {
+				return err
+			}
+
+			return record(ctx, tx)
+		}, nil)
+	}
+
+	if err != nil {
+		return fmt.Errorf("migration %v %v: %w", mig, direction, err)
+	}
+
+	return nil
+}
+
+// statementTimeoutSetting returns the statement_timeout of migrations in milliseconds
+func (m *Migrator) statementTimeoutSetting() string {
+	ms := m.statementTimeout.Milliseconds()
+	if ms == 0 && m.statementTimeout > 0 {
+		ms = 1
+	}
+
+	return strconv.FormatInt(ms, 10)
+}
+
+func (m *Migrator) byVersion() map[int64]*Migration {
+	known := make(map[int64]*Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = mig
+	}
+
+	return known
+}
+
+func changed(mig *Migration, a appliedMigration) bool {
+	return mig.Checksum() != a.checksum
+}
+
+func sortedApplied(applied map[int64]appliedMigration) []appliedMigration {
+	sorted := make([]appliedMigration, 0, len(applied))
+	for _, a := range applied {
+		sorted = append(sorted, a)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].version < sorted[j].version
+	})
+
+	return sorted
+}
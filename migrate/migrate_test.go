@@ -0,0 +1,164 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	psql "github.com/airspacetechnologies/go-psql"
+	"github.com/stretchr/testify/require"
+)
+
+var testFiles = fstest.MapFS{
+	"0001_create_flights.up.sql":   {Data: []byte("CREATE TABLE migrate_flights (id serial PRIMARY KEY, gate text);")},
+	"0001_create_flights.down.sql": {Data: []byte("DROP TABLE migrate_flights;")},
+	"0002_index_gate.up.sql":       {Data: []byte("-- psql:no-transaction\nCREATE INDEX CONCURRENTLY migrate_flights_gate ON migrate_flights (gate);")},
+	"0002_index_gate.down.sql":     {Data: []byte("DROP INDEX migrate_flights_gate;")},
+}
+
+func TestMigrator(t *testing.T) {
+	c := psql.NewClient(nil)
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+
+	ctx := context.Background()
+	defer func() {
+		_, _ = c.Exec("DROP TABLE IF EXISTS migrate_flights, migrate_test_migrations")
+		_ = c.Close()
+	}()
+
+	migrations, err := ReadFS(testFiles, ".")
+	require.Nil(t, err)
+
+	migrations = append(migrations, &Migration{
+		Version: 3,
+		Name:    "seed_flights",
+		Up: Step{Func: func(ctx context.Context, c psql.QueryClient) error {
+			_, err := c.ExecContext(ctx, "INSERT INTO migrate_flights (gate) VALUES ('A1')")
+			return err
+		}},
+	})
+
+	// dry runs don't create the table
+	dry, err := New(c, migrations, WithTable("migrate_test_migrations"), WithDryRun())
+	require.Nil(t, err)
+
+	planned, err := dry.Up(ctx)
+	require.Nil(t, err)
+	require.Len(t, planned, 3)
+
+	m, err := New(c, migrations, WithTable("migrate_test_migrations"))
+	require.Nil(t, err)
+
+	statuses, err := m.Status(ctx)
+	require.Nil(t, err)
+	require.Len(t, statuses, 3)
+	require.False(t, statuses[0].Applied)
+
+	ran, err := m.Up(ctx)
+	require.Nil(t, err)
+	require.Equal(t, migrations, ran)
+
+	ran, err = m.Up(ctx)
+	require.Nil(t, err)
+	require.Empty(t, ran)
+
+	var gates []string
+	require.Nil(t, c.RawSelect(ctx, &gates, "SELECT gate FROM migrate_flights"))
+	require.Equal(t, []string{"A1"}, gates)
+
+	statuses, err = m.Status(ctx)
+	require.Nil(t, err)
+	for _, s := range statuses {
+		require.True(t, s.Applied)
+		require.False(t, s.Changed)
+		require.False(t, s.AppliedAt.IsZero())
+	}
+
+	// go migrations without a down step can't be rolled back
+	_, err = m.Down(ctx, 0)
+	require.True(t, errors.Is(err, ErrIrreversible), err)
+
+	_, err = c.Exec("DELETE FROM migrate_test_migrations WHERE version = 3")
+	require.Nil(t, err)
+
+	planned, err = dry.Down(ctx, 0)
+	require.Nil(t, err)
+	require.Equal(t, []*Migration{migrations[1], migrations[0]}, planned)
+
+	ran, err = m.Down(ctx, 1)
+	require.Nil(t, err)
+	require.Equal(t, []*Migration{migrations[1]}, ran)
+
+	// changed migrations are detected
+	changedMigrations := []*Migration{{Version: 1, Name: "create_flights", Up: Step{SQL: "CREATE TABLE changed ()"}}}
+	changedM, err := New(c, changedMigrations, WithTable("migrate_test_migrations"))
+	require.Nil(t, err)
+
+	_, err = changedM.Up(ctx)
+	require.True(t, errors.Is(err, ErrChecksumMismatch), err)
+
+	statuses, err = changedM.Status(ctx)
+	require.Nil(t, err)
+	require.True(t, statuses[0].Changed)
+
+	// failed migrations aren't recorded
+	failing := append(migrations[:2:2], &Migration{Version: 4, Name: "fail", Up: Step{SQL: "ALTER TABLE migrate_flights ADD gate text"}})
+	failingM, err := New(c, failing, WithTable("migrate_test_migrations"))
+	require.Nil(t, err)
+
+	ran, err = failingM.Up(ctx)
+	require.NotNil(t, err)
+	require.Equal(t, []*Migration{migrations[1]}, ran)
+
+	statuses, err = failingM.Status(ctx)
+	require.Nil(t, err)
+	require.False(t, statuses[2].Applied)
+
+	ran, err = failingM.Down(ctx, 0)
+	require.Nil(t, err)
+	require.Len(t, ran, 2)
+}
+
+func TestMigrator_StatementTimeout(t *testing.T) {
+	c := psql.NewClient(nil, psql.WithStatementTimeout(10*time.Millisecond))
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+
+	ctx := context.Background()
+	defer func() {
+		_, _ = c.Exec("DROP TABLE IF EXISTS migrate_timeout_migrations")
+		_ = c.Close()
+	}()
+
+	// the client's default doesn't apply to migrations
+	var setting string
+	slow := []*Migration{{
+		Version: 1,
+		Name:    "slow",
+		Up: Step{Func: func(ctx context.Context, c psql.QueryClient) error {
+			if _, err := c.ExecContext(ctx, "SELECT pg_sleep(0.05)"); err != nil {
+				return err
+			}
+			return c.(*psql.Tx).QueryRowContext(ctx, "SHOW statement_timeout").Scan(&setting)
+		}},
+		Down: Step{SQL: "SELECT pg_sleep(0.05)"},
+	}}
+
+	m, err := New(c, slow, WithTable("migrate_timeout_migrations"))
+	require.Nil(t, err)
+
+	_, err = m.Up(ctx)
+	require.Nil(t, err)
+	require.Equal(t, "0", setting)
+
+	m, err = New(c, slow, WithTable("migrate_timeout_migrations"), WithStatementTimeout(10*time.Millisecond))
+	require.Nil(t, err)
+
+	_, err = m.Down(ctx, 0)
+	require.True(t, errors.Is(err, psql.ErrQueryCanceled), err)
+}
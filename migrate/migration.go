@@ -0,0 +1,157 @@
+// Package migrate applies versioned schema migrations with a psql.Client.
+//
+// Migrations are SQL files named `<version>_<name>.up.sql` and `<version>_<name>.down.sql`, e.g.
+// `0001_create_flights.up.sql`, or Go functions. Each migration runs in a transaction unless its file
+// contains the line
+//
+//	-- psql:no-transaction
+//
+// which is needed for statements like CREATE INDEX CONCURRENTLY. Such files are sent as a single query
+// so they should hold a single statement.
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	psql "github.com/airspacetechnologies/go-psql"
+)
+
+// NoTransactionDirective opts a SQL file out of running in a transaction
+const NoTransactionDirective = "-- psql:no-transaction"
+
+var fileRegexp = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a version of the schema
+type Migration struct {
+	Version int64
+	Name    string
+	Up      Step
+	Down    Step // empty when the migration can't be rolled back
+}
+
+// Step is a direction of a migration, either SQL or a Go function
+type Step struct {
+	SQL  string
+	Func func(ctx context.Context, c psql.QueryClient) error
+
+	// NoTransaction runs the step on the client instead of in a transaction, it is set by the
+	// NoTransactionDirective in SQL files
+	NoTransaction bool
+}
+
+func (s Step) empty() bool {
+	return s.SQL == "" && s.Func == nil
+}
+
+func (s Step) run(ctx context.Context, c psql.QueryClient) error {
+	if s.Func != nil {
+		return s.Func(ctx, c)
+	}
+
+	_, err := c.ExecContext(ctx, s.SQL)
+	return err
+}
+
+// Checksum returns the SHA-256 of the up SQL which is recorded when the migration is applied to detect
+// changes of applied migrations, it is empty for Go migrations
+func (m *Migration) Checksum() string {
+	if m.Up.SQL == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(m.Up.SQL))
+	return hex.EncodeToString(sum[:])
+}
+
+func (m *Migration) String() string {
+	return fmt.Sprintf("%d_%v", m.Version, m.Name)
+}
+
+// ReadDir reads the migration files of dir, see ReadFS
+func ReadDir(dir string) ([]*Migration, error) {
+	return ReadFS(os.DirFS(dir), ".")
+}
+
+// ReadFS reads the migration files of dir in fsys, e.g. an embed.FS. Other files are ignored except for
+// .sql files not named like migrations which are reported as errors.
+func ReadFS(fsys fs.FS, dir string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		match := fileRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file %v must be named <version>_<name>.up.sql or <version>_<name>.down.sql", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration file %v: %w", entry.Name(), err)
+		}
+
+		b, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has files named %v and %v", version, m.Name, match[2])
+		}
+
+		step := &m.Up
+		if match[3] == "down" {
+			step = &m.Down
+		}
+		*step = Step{SQL: string(b), NoTransaction: hasNoTransactionDirective(string(b))}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up.empty() {
+			return nil, fmt.Errorf("migration %v has no up file", m)
+		}
+		migrations = append(migrations, m)
+	}
+	sortMigrations(migrations)
+
+	return migrations, nil
+}
+
+func hasNoTransactionDirective(sql string) bool {
+	s := bufio.NewScanner(strings.NewReader(sql))
+	for s.Scan() {
+		if strings.TrimSpace(s.Text()) == NoTransactionDirective {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortMigrations(migrations []*Migration) {
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+}
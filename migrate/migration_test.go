@@ -0,0 +1,88 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	psql "github.com/airspacetechnologies/go-psql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0002_add_gate.up.sql":         {Data: []byte("ALTER TABLE flights ADD gate text;")},
+		"migrations/0002_add_gate.down.sql":       {Data: []byte("ALTER TABLE flights DROP gate;")},
+		"migrations/0001_create_flights.up.sql":   {Data: []byte("CREATE TABLE flights (id serial);")},
+		"migrations/0003_index_gate.up.sql":       {Data: []byte("-- psql:no-transaction\nCREATE INDEX CONCURRENTLY flights_gate ON flights (gate);")},
+		"migrations/0003_index_gate.down.sql":     {Data: []byte("DROP INDEX flights_gate;")},
+		"migrations/README.md":                    {Data: []byte("ignored")},
+		"migrations/archive/0000_old.up.sql":      {Data: []byte("ignored")},
+		"other/0001_create_airports.up.sql":       {Data: []byte("CREATE TABLE airports (id serial);")},
+		"other/0001_create_airports.down.sql.bak": {Data: []byte("ignored")},
+	}
+
+	migrations, err := ReadFS(fsys, "migrations")
+	require.Nil(t, err)
+	require.Len(t, migrations, 3)
+
+	require.Equal(t, &Migration{Version: 1, Name: "create_flights", Up: Step{SQL: "CREATE TABLE flights (id serial);"}}, migrations[0])
+	require.Equal(t, int64(2), migrations[1].Version)
+	require.Equal(t, "ALTER TABLE flights DROP gate;", migrations[1].Down.SQL)
+	require.True(t, migrations[2].Up.NoTransaction)
+	require.False(t, migrations[2].Down.NoTransaction)
+	require.Equal(t, "3_index_gate", migrations[2].String())
+
+	tcs := map[string]struct {
+		Files fstest.MapFS
+		Err   string
+	}{
+		"bad name": {
+			Files: fstest.MapFS{"m/create_flights.sql": {}},
+			Err:   "migration file create_flights.sql must be named <version>_<name>.up.sql or <version>_<name>.down.sql",
+		},
+		"names differ": {
+			Files: fstest.MapFS{"m/0001_a.up.sql": {Data: []byte("SELECT 1")}, "m/0001_b.down.sql": {}},
+			Err:   "migration 1 has files named a and b",
+		},
+		"no up": {
+			Files: fstest.MapFS{"m/0001_a.down.sql": {Data: []byte("SELECT 1")}},
+			Err:   "migration 1_a has no up file",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadFS(tc.Files, "m")
+			require.EqualError(t, err, tc.Err)
+		})
+	}
+}
+
+func TestMigration_Checksum(t *testing.T) {
+	m := &Migration{Up: Step{SQL: "SELECT 1"}}
+	require.Len(t, m.Checksum(), 64)
+	require.Equal(t, m.Checksum(), (&Migration{Up: Step{SQL: "SELECT 1"}, Down: Step{SQL: "SELECT 2"}}).Checksum())
+	require.NotEqual(t, m.Checksum(), (&Migration{Up: Step{SQL: "SELECT 2"}}).Checksum())
+
+	goMigration := &Migration{Up: Step{Func: func(context.Context, psql.QueryClient) error { return nil }}}
+	require.Equal(t, "", goMigration.Checksum())
+}
+
+func TestNew(t *testing.T) {
+	up := Step{SQL: "SELECT 1"}
+
+	m, err := New(nil, []*Migration{{Version: 2, Up: up}, {Version: 1, Up: up}}, WithTable("reporting.migrations"))
+	require.Nil(t, err)
+	require.Equal(t, `"reporting"."migrations"`, m.table)
+	require.Equal(t, int64(1), m.migrations[0].Version)
+
+	_, err = New(nil, []*Migration{{Version: 1, Name: "a", Up: up}, {Version: 1, Name: "b", Up: up}})
+	require.EqualError(t, err, "migrations 1_a and 1_b have the same version")
+
+	_, err = New(nil, []*Migration{{Version: 1, Name: "a"}})
+	require.EqualError(t, err, "migration 1_a has no up step")
+
+	_, err = New(nil, nil, WithTable("migrations m"))
+	require.EqualError(t, err, `invalid migrations table "migrations m"`)
+}
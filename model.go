@@ -5,6 +5,8 @@ import (
 	"reflect"
 )
 
+// TableName can be schema qualified ("reporting.flights"), the schema can also be
+// provided by implementing SchemaNamer
 type Model interface {
 	TableName() string
 }
@@ -0,0 +1,249 @@
+package psql
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// NamedArgs holds the values for a query using named parameters, see Named.
+type NamedArgs struct {
+	params interface{}
+}
+
+// Named wraps Attrs, a map with string keys, or a struct (or pointer to one) with sql tags so that it can
+// be passed as the only argument to WhereRaw, RawQuery or RawSelect. The query then refers to the values by
+// name using :name or @name and they get renumbered into $n placeholders:
+//
+//	c.Select("flights").WhereRaw("code LIKE 'AB%' AND origin = :origin", Named(Attrs{"origin": "SFO"}))
+//
+// Names inside string literals, quoted identifiers, comments and :: casts are left untouched.
+func Named(params interface{}) NamedArgs {
+	return NamedArgs{params: params}
+}
+
+// namedArgs returns the NamedArgs if they are the only value passed in
+func namedArgs(vals []interface{}) (NamedArgs, bool) {
+	if len(vals) != 1 {
+		return NamedArgs{}, false
+	}
+
+	na, ok := vals[0].(NamedArgs)
+	return na, ok
+}
+
+// boundQuery is sql with its named parameters resolved to values
+type boundQuery struct {
+	parts  []string      // sql before each parameter, the last part follows the last parameter
+	argIdx []int         // index into vals for each parameter
+	vals   []interface{} // one value per distinct name
+	names  []string      // name of each value
+}
+
+func bindNamed(q string, na NamedArgs) (*boundQuery, error) {
+	parts, names := parseNamed(q)
+
+	bq := &boundQuery{parts: parts, argIdx: make([]int, len(names))}
+
+	seen := make(map[string]int, len(names))
+	for i, name := range names {
+		if idx, ok := seen[name]; ok {
+			bq.argIdx[i] = idx
+			continue
+		}
+
+		v, err := lookupNamed(na.params, name)
+		if err != nil {
+			return nil, err
+		}
+
+		idx := len(bq.vals)
+		seen[name] = idx
+		bq.argIdx[i] = idx
+		bq.vals = append(bq.vals, v)
+		bq.names = append(bq.names, name)
+	}
+
+	return bq, nil
+}
+
+// SQL returns the query with parameters numbered from $start
+func (bq *boundQuery) SQL(start int) string {
+	var b StringsBuilder
+	for i, idx := range bq.argIdx {
+		b.WriteStrings(bq.parts[i], "$", strconv.Itoa(start+idx))
+	}
+	b.WriteString(bq.parts[len(bq.parts)-1])
+
+	return b.String()
+}
+
+func lookupNamed(params interface{}, name string) (interface{}, error) {
+	if attrs, ok := params.(Attrs); ok {
+		v, ok := attrs[name]
+		if !ok {
+			return nil, fmt.Errorf("missing value for named parameter %q", name)
+		}
+		return v, nil
+	}
+
+	v := reflect.ValueOf(params)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		mv := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		if !mv.IsValid() {
+			return nil, fmt.Errorf("missing value for named parameter %q", name)
+		}
+		return mv.Interface(), nil
+	case v.Kind() == reflect.Struct:
+		idxs, ok := indexes(v.Type())[name]
+		if !ok {
+			return nil, fmt.Errorf("missing value for named parameter %q", name)
+		}
+		return fieldAt(v, idxs).Interface(), nil
+	default:
+		return nil, fmt.Errorf("unsupported named parameters type %T", params)
+	}
+}
+
+// parseNamed splits q around :name and @name parameters. It skips string literals, quoted identifiers,
+// dollar quoted strings, comments and :: casts.
+func parseNamed(q string) ([]string, []string) {
+	var parts, names []string
+	var b strings.Builder
+
+	n := len(q)
+	for i := 0; i < n; {
+		c := q[i]
+
+		switch {
+		case c == '\'':
+			// E'' strings allow backslash escapes
+			escapes := i > 0 && (q[i-1] == 'E' || q[i-1] == 'e') && (i < 2 || !isIdentChar(q[i-2]))
+			j := skipQuoted(q, i, '\'', escapes)
+			b.WriteString(q[i:j])
+			i = j
+		case c == '"':
+			j := skipQuoted(q, i, '"', false)
+			b.WriteString(q[i:j])
+			i = j
+		case c == '-' && i+1 < n && q[i+1] == '-':
+			j := strings.IndexByte(q[i:], '\n')
+			if j == -1 {
+				j = n
+			} else {
+				j += i
+			}
+			b.WriteString(q[i:j])
+			i = j
+		case c == '/' && i+1 < n && q[i+1] == '*':
+			j := skipBlockComment(q, i)
+			b.WriteString(q[i:j])
+			i = j
+		case c == '$':
+			j := skipDollarQuoted(q, i)
+			b.WriteString(q[i:j])
+			i = j
+		case c == ':' && i+1 < n && q[i+1] == ':':
+			b.WriteString("::")
+			i += 2
+		case (c == ':' || c == '@') && i+1 < n && isIdentStart(q[i+1]):
+			j := i + 1
+			for j < n && isIdentChar(q[j]) {
+				j++
+			}
+			parts = append(parts, b.String())
+			names = append(names, q[i+1:j])
+			b.Reset()
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	parts = append(parts, b.String())
+
+	return parts, names
+}
+
+// skipQuoted returns the index after the closing quote, doubled quotes are escapes
+func skipQuoted(q string, i int, quote byte, backslashEscapes bool) int {
+	n := len(q)
+	for j := i + 1; j < n; j++ {
+		switch {
+		case backslashEscapes && q[j] == '\\':
+			j++
+		case q[j] == quote:
+			if j+1 < n && q[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return n
+}
+
+// skipBlockComment returns the index after the comment, block comments nest in postgres
+func skipBlockComment(q string, i int) int {
+	n := len(q)
+	depth := 0
+	for j := i; j < n-1; j++ {
+		switch {
+		case q[j] == '/' && q[j+1] == '*':
+			depth++
+			j++
+		case q[j] == '*' && q[j+1] == '/':
+			depth--
+			j++
+			if depth == 0 {
+				return j + 1
+			}
+		}
+	}
+	return n
+}
+
+// skipDollarQuoted returns the index after a $tag$...$tag$ string, or after the $ if it does not start one
+func skipDollarQuoted(q string, i int) int {
+	if i > 0 && isIdentChar(q[i-1]) {
+		// $ is allowed inside identifiers
+		return i + 1
+	}
+
+	j := i + 1
+	if j < len(q) && !isIdentStart(q[j]) && q[j] != '$' {
+		// positional parameter like $1 or a lone $
+		return j
+	}
+
+	for j < len(q) && isIdentChar(q[j]) {
+		j++
+	}
+
+	if j >= len(q) || q[j] != '$' {
+		return i + 1
+	}
+
+	tag := q[i : j+1]
+	end := strings.Index(q[j+1:], tag)
+	if end == -1 {
+		return len(q)
+	}
+
+	return j + 1 + end + len(tag)
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
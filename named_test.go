@@ -0,0 +1,107 @@
+package psql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindNamed(t *testing.T) {
+	type params struct {
+		ID   int    `sql:"id"`
+		Name string `sql:"name"`
+	}
+
+	tcs := map[string]struct {
+		Query    string
+		Params   interface{}
+		Start    int
+		Expected string
+		Vals     []interface{}
+		Error    bool
+	}{
+		"attrs": {
+			Query:    "id = :id AND name = @name",
+			Params:   Attrs{"id": 1, "name": "a"},
+			Start:    1,
+			Expected: "id = $1 AND name = $2",
+			Vals:     []interface{}{1, "a"},
+		},
+		"repeated name and offset": {
+			Query:    "id = :id OR parent_id = :id",
+			Params:   Attrs{"id": 1},
+			Start:    3,
+			Expected: "id = $3 OR parent_id = $3",
+			Vals:     []interface{}{1},
+		},
+		"struct": {
+			Query:    "id = :id AND name = :name",
+			Params:   &params{ID: 2, Name: "b"},
+			Start:    1,
+			Expected: "id = $1 AND name = $2",
+			Vals:     []interface{}{2, "b"},
+		},
+		"map": {
+			Query:    "name = :name",
+			Params:   map[string]string{"name": "c"},
+			Start:    1,
+			Expected: "name = $1",
+			Vals:     []interface{}{"c"},
+		},
+		"skips literals comments and casts": {
+			Query:    `name LIKE 'ab:c%' AND "x:y" = :id::text AND e = E'\':z' AND d = $$:w$$ -- :v` + "\n" + `/* :u /* :t */ */ AND a = @name`,
+			Params:   Attrs{"id": 1, "name": "a"},
+			Start:    1,
+			Expected: `name LIKE 'ab:c%' AND "x:y" = $1::text AND e = E'\':z' AND d = $$:w$$ -- :v` + "\n" + `/* :u /* :t */ */ AND a = $2`,
+			Vals:     []interface{}{1, "a"},
+		},
+		"no parameters": {
+			Query:    "name LIKE 'abc%'",
+			Params:   nil,
+			Start:    1,
+			Expected: "name LIKE 'abc%'",
+		},
+		"missing value": {
+			Query:  "id = :id",
+			Params: Attrs{},
+			Error:  true,
+		},
+		"unsupported params": {
+			Query:  "id = :id",
+			Params: 5,
+			Error:  true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			bq, err := bindNamed(tc.Query, Named(tc.Params))
+			if tc.Error {
+				require.NotNil(t, err)
+				return
+			}
+
+			require.Nil(t, err)
+			require.Equal(t, tc.Expected, bq.SQL(tc.Start))
+			require.Equal(t, tc.Vals, bq.vals)
+		})
+	}
+}
+
+func TestQuery_WhereRawNamed(t *testing.T) {
+	q := SelectQuery(nil, "mock_models").
+		Where(Attrs{"int_field": 1}).
+		WhereRaw("string_field LIKE 'a%' AND float_field > :min", Named(Attrs{"min": 2.5})).
+		WhereRaw("bool_field = %v", true)
+
+	where, vals, cols := q.whereClause(1)
+	require.Equal(t, `"int_field" = $1 AND string_field LIKE 'a%' AND float_field > $2 AND bool_field = $3`, where)
+	require.Equal(t, []interface{}{1, 2.5, true}, vals)
+	require.Equal(t, []string{"int_field", "min", ""}, cols)
+
+	q = SelectQuery(nil, "mock_models").WhereRaw("int_field = :missing", Named(Attrs{}))
+	require.NotNil(t, q.buildErr())
+
+	q = SelectQuery(nil, "mock_models").Or(SubQuery().WhereRaw("int_field = :missing", Named(Attrs{})))
+	require.NotNil(t, q.buildErr())
+}
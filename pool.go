@@ -0,0 +1,121 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// sqlMaxIdleConns is the number of idle connections database/sql keeps unless told otherwise
+const sqlMaxIdleConns = 2
+
+// poolConfig holds the connection pool settings of a Config, see Config.MaxOpenConns
+type poolConfig struct {
+	maxOpenConns    int
+	maxIdleConns    int
+	connMaxLifetime time.Duration
+	connMaxIdleTime time.Duration
+	minConns        int
+}
+
+func newPoolConfig(cfg *Config) poolConfig {
+	if cfg == nil {
+		return poolConfig{}
+	}
+
+	return poolConfig{
+		maxOpenConns:    cfg.MaxOpenConns,
+		maxIdleConns:    cfg.MaxIdleConns,
+		connMaxLifetime: cfg.ConnMaxLifetime,
+		connMaxIdleTime: cfg.ConnMaxIdleTime,
+		minConns:        cfg.MinConns,
+	}
+}
+
+func (p poolConfig) validate() error {
+	if p.minConns < 0 {
+		return fmt.Errorf("MinConns must not be negative, got %d", p.minConns)
+	}
+
+	p = p.withDefaults()
+
+	unlimited := p.maxOpenConns <= 0
+	if !unlimited && p.maxIdleConns > p.maxOpenConns {
+		return fmt.Errorf("MaxIdleConns (%d) must not exceed MaxOpenConns (%d)", p.maxIdleConns, p.maxOpenConns)
+	}
+
+	if p.minConns > 0 && p.minConns > p.maxIdleConns {
+		return fmt.Errorf("MinConns (%d) must not exceed MaxIdleConns (%d) or the warm connections are closed", p.minConns, p.maxIdleConns)
+	}
+
+	return nil
+}
+
+// withDefaults returns the settings database/sql uses, zero values keep its defaults and negative
+// values are its unlimited or disabled values
+func (p poolConfig) withDefaults() poolConfig {
+	if p.maxOpenConns < 0 {
+		p.maxOpenConns = 0
+	}
+
+	switch {
+	case p.maxIdleConns == 0:
+		p.maxIdleConns = sqlMaxIdleConns
+		if p.maxOpenConns > 0 && p.maxIdleConns > p.maxOpenConns {
+			p.maxIdleConns = p.maxOpenConns
+		}
+	case p.maxIdleConns < 0:
+		p.maxIdleConns = 0
+	}
+
+	if p.connMaxLifetime < 0 {
+		p.connMaxLifetime = 0
+	}
+
+	if p.connMaxIdleTime < 0 {
+		p.connMaxIdleTime = 0
+	}
+
+	return p
+}
+
+// apply sets the configured limits on db, database/sql's defaults are kept for the others
+func (p poolConfig) apply(db *sql.DB) {
+	if p.maxOpenConns != 0 {
+		db.SetMaxOpenConns(p.maxOpenConns)
+	}
+	if p.maxIdleConns != 0 {
+		db.SetMaxIdleConns(p.maxIdleConns)
+	}
+	if p.connMaxLifetime != 0 {
+		db.SetConnMaxLifetime(p.connMaxLifetime)
+	}
+	if p.connMaxIdleTime != 0 {
+		db.SetConnMaxIdleTime(p.connMaxIdleTime)
+	}
+}
+
+// warm opens minConns connections and returns them to the idle pool
+func (p poolConfig) warm(ctx context.Context, db *sql.DB) error {
+	conns := make([]*sql.Conn, 0, p.minConns)
+	defer func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	}()
+
+	for i := 0; i < p.minConns; i++ {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to open %d warm connections: %w", p.minConns, err)
+		}
+		conns = append(conns, conn)
+
+		if err := conn.PingContext(ctx); err != nil {
+			return fmt.Errorf("unable to open %d warm connections: %w", p.minConns, err)
+		}
+	}
+
+	return nil
+}
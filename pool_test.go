@@ -0,0 +1,90 @@
+package psql
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolConfig(t *testing.T) {
+	tcs := map[string]struct {
+		Config   Config
+		Expected poolConfig
+		Err      string
+	}{
+		"defaults": {
+			Expected: poolConfig{maxIdleConns: 2},
+		},
+		"idle lowered to open": {
+			Config:   Config{MaxOpenConns: 1, ConnMaxLifetime: time.Minute},
+			Expected: poolConfig{maxOpenConns: 1, maxIdleConns: 1, connMaxLifetime: time.Minute},
+		},
+		"unlimited": {
+			Config:   Config{MaxOpenConns: -1, MaxIdleConns: 50, ConnMaxLifetime: -1, ConnMaxIdleTime: -1, MinConns: 5},
+			Expected: poolConfig{maxIdleConns: 50, minConns: 5},
+		},
+		"no idle": {
+			Config:   Config{MaxIdleConns: -1},
+			Expected: poolConfig{},
+		},
+		"idle exceeds open": {
+			Config: Config{MaxOpenConns: 5, MaxIdleConns: 6},
+			Err:    "MaxIdleConns (6) must not exceed MaxOpenConns (5)",
+		},
+		"min exceeds idle": {
+			Config: Config{MinConns: 3},
+			Err:    "MinConns (3) must not exceed MaxIdleConns (2) or the warm connections are closed",
+		},
+		"negative min": {
+			Config: Config{MinConns: -1},
+			Err:    "MinConns must not be negative, got -1",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			p := newPoolConfig(&tc.Config)
+
+			err := p.validate()
+			if tc.Err != "" {
+				require.EqualError(t, err, tc.Err)
+				return
+			}
+
+			require.Nil(t, err)
+			require.Equal(t, tc.Expected, p.withDefaults())
+		})
+	}
+}
+
+func TestPoolConfig_Apply(t *testing.T) {
+	db, err := sql.Open("postgres", "")
+	require.Nil(t, err)
+	defer db.Close()
+
+	// unconfigured limits keep database/sql's defaults
+	newPoolConfig(&Config{}).apply(db)
+	require.Equal(t, 0, db.Stats().MaxOpenConnections)
+
+	newPoolConfig(&Config{MaxOpenConns: 7}).apply(db)
+	require.Equal(t, 7, db.Stats().MaxOpenConnections)
+}
+
+func TestClient_StartPool(t *testing.T) {
+	c := NewClient(&Config{MaxOpenConns: 5, MinConns: 6})
+	require.NotNil(t, c.Start(""))
+	require.False(t, c.Started())
+
+	c = NewClient(&Config{MaxOpenConns: 5, MaxIdleConns: 3, MinConns: 3})
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+	defer c.Close()
+
+	stats := c.Stats()
+	require.Equal(t, 5, stats.MaxOpenConnections)
+	require.Equal(t, 3, stats.OpenConnections)
+	require.Equal(t, 3, stats.Idle)
+}
@@ -38,7 +38,7 @@ func selectQuery(table string, columns []string, where string, orderBys []string
 	} else {
 		cols = strings.Join(quoteStrings(columns...), ", ")
 	}
-	b.WriteStrings("SELECT ", cols, " FROM ", Quote(table))
+	b.WriteStrings("SELECT ", cols, " FROM ", QuoteTable(table))
 
 	if where != "" {
 		b.WriteStrings(" WHERE ", where)
@@ -68,7 +68,7 @@ func insertQuery(table string, cols []string, returning []string) string {
 
 	returnCols := strings.Join(quoteStrings(returning...), ", ")
 
-	b.WriteStrings("INSERT INTO ", Quote(table), " (", colsStr, ") VALUES (", valsStr, ") RETURNING ", returnCols)
+	b.WriteStrings("INSERT INTO ", QuoteTable(table), " (", colsStr, ") VALUES (", valsStr, ") RETURNING ", returnCols)
 	return b.String()
 }
 
@@ -79,7 +79,7 @@ func updateQuery(table string, cols []string, where string, returning []string)
 	colsStr := strings.Join(quoteStrings(cols...), ", ")
 	valsStr := strings.Join(placeHolders, ", ")
 
-	b.WriteStrings("UPDATE ", Quote(table), " SET (", colsStr, ") = ", "ROW(", valsStr, ")")
+	b.WriteStrings("UPDATE ", QuoteTable(table), " SET (", colsStr, ") = ", "ROW(", valsStr, ")")
 
 	if where != "" {
 		b.WriteStrings(" WHERE ", where)
@@ -94,7 +94,7 @@ func updateQuery(table string, cols []string, where string, returning []string)
 
 func deleteQuery(table string, where string, returning []string) string {
 	var b StringsBuilder
-	b.WriteStrings("DELETE FROM ", Quote(table))
+	b.WriteStrings("DELETE FROM ", QuoteTable(table))
 
 	if where != "" {
 		b.WriteStrings(" WHERE ", where)
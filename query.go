@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"errors"
 )
@@ -23,6 +24,9 @@ type Query struct {
 	orderBys   []string
 	limit      int
 	returning  []string // holds columns to return for insert, update, and delete
+	err        error    // first error from building the query, returned by Exec
+	timeout    time.Duration
+	timeoutSet bool
 }
 
 func SubQuery() *Query {
@@ -45,7 +49,22 @@ func (q *Query) WhereNot(attrs map[string]interface{}) *Query {
 }
 
 // Instead of the where clause using field = $1 use field = %v
+//
+// Passing Named(params) as the only value uses :name or @name parameters instead of %v, see Named
 func (q *Query) WhereRaw(raw string, vals ...interface{}) *Query {
+	if na, ok := namedArgs(vals); ok {
+		bq, err := bindNamed(raw, na)
+		if err != nil {
+			if q.err == nil {
+				q.err = err
+			}
+			return q
+		}
+
+		q.conditions = append(q.conditions, &condition{named: bq})
+		return q
+	}
+
 	q.conditions = append(q.conditions, &condition{raw: raw, rawVals: vals})
 	return q
 }
@@ -79,6 +98,15 @@ func (q *Query) Returning(cols ...string) *Query {
 	return q
 }
 
+// Timeout caps how long the query may run on the server using SET LOCAL statement_timeout.
+// Outside of a transaction the query is wrapped in one, which is committed once the result is closed.
+// It overrides the client's default, a timeout of 0 disables it for this query.
+func (q *Query) Timeout(d time.Duration) *Query {
+	q.timeout = d
+	q.timeoutSet = true
+	return q
+}
+
 /*
 Exec() executes the query either with db.Exec() (RowsAffected) or db.Query() (Rows) depending on the query
 
@@ -95,34 +123,95 @@ func (q *Query) Exec(ctx context.Context) (*QueryResult, error) {
 		return &r, fmt.Errorf("client or db is nil")
 	}
 
+	if err := q.buildErr(); err != nil {
+		return &r, err
+	}
+
+	ctx = withQueryInfo(ctx, Operation(q.action), q.tableName)
+	c := resolveClient(ctx, q.client)
+	if q.action == "select" {
+		c = readClient(ctx, c)
+	}
+	timeout := q.statementTimeout(c)
+
+	release, err := holdRows(c)
+	if err != nil {
+		return &r, err
+	}
+
+	// only explicit timeouts are applied per statement, the client's default is set on its connections
+	var finish func(error) error
+	if q.timeoutSet {
+		if c, finish, err = withStatementTimeout(ctx, c, timeout); err != nil {
+			release()
+			return &r, err
+		}
+	}
+
+	err = q.exec(ctx, c, &r)
+	if err != nil || r.Rows == nil {
+		release()
+	} else {
+		r.release = release
+	}
+
+	if finish != nil {
+		if err != nil || r.Rows == nil {
+			err = finish(err)
+		} else {
+			// the transaction ends once the rows are closed
+			r.finish = finish
+		}
+	}
+
+	r.timeout = timeout
+
+	return &r, r.error(err)
+}
+
+func (q *Query) exec(ctx context.Context, c QueryClient, r *QueryResult) error {
+	var err error
+
 	switch q.action {
 	case "select":
-		rows, err := q.execSelect(ctx)
-		r.Rows = rows
-		return &r, err
+		r.Rows, err = q.execSelect(ctx, c, r)
 	case "insert":
-		rows, err := q.execInsert(ctx)
-		r.Rows = rows
-		return &r, err
+		r.Rows, err = q.execInsert(ctx, c, r)
 	case "update":
-		var err error
 		if len(q.returning) == 0 {
-			r.RowsAffected, err = q.execUpdate(ctx)
+			r.RowsAffected, err = q.execUpdate(ctx, c, r)
 		} else {
-			r.Rows, err = q.execUpdateR(ctx)
+			r.Rows, err = q.execUpdateR(ctx, c, r)
 		}
-		return &r, err
 	case "delete":
-		var err error
 		if len(q.returning) == 0 {
-			r.RowsAffected, err = q.execDelete(ctx)
+			r.RowsAffected, err = q.execDelete(ctx, c, r)
 		} else {
-			r.Rows, err = q.execDeleteR(ctx)
+			r.Rows, err = q.execDeleteR(ctx, c, r)
 		}
-		return &r, err
 	default:
-		return &r, fmt.Errorf("unsupported action %v", q.action)
+		err = fmt.Errorf("unsupported action %v", q.action)
 	}
+
+	return err
+}
+
+// statement describes the SQL built by the query for its errors, see QueryError
+func (q *Query) statement(c QueryClient, qs string, vals []interface{}, argCols []string) *statement {
+	return newStatement(c, Operation(q.action), q.tableName, qs, vals, argCols)
+}
+
+// statementTimeout returns the timeout set on the query or the default of the client it runs on
+func (q *Query) statementTimeout(c QueryClient) time.Duration {
+	if q.timeoutSet {
+		return q.timeout
+	}
+
+	if st, ok := c.(statementTimeouter); ok {
+		return st.StatementTimeout()
+	}
+
+	return 0
 }
 
 func (q *Query) Slice(ctx context.Context, outSlicePtr interface{}) error {
@@ -154,11 +243,12 @@ func SelectQuery(c QueryClient, tableName string, cols ...string) *Query {
 	}
 }
 
-func (q *Query) execSelect(ctx context.Context) (*sql.Rows, error) {
-	where, vals := q.whereClause(1)
+func (q *Query) execSelect(ctx context.Context, c QueryClient, r *QueryResult) (*sql.Rows, error) {
+	where, vals, argCols := q.whereClause(1)
 	qs := selectQuery(q.tableName, q.columns, where, q.orderBys, q.limit)
 
-	return q.client.QueryContext(ctx, qs, vals...)
+	r.stmt = q.statement(c, qs, vals, argCols)
+	return c.QueryContext(withArgColumns(ctx, argCols), qs, vals...)
 }
 
 // INSERT
@@ -172,7 +262,7 @@ func InsertQuery(c QueryClient, tableName string, attrs map[string]interface{})
 	}
 }
 
-func (q *Query) execInsert(ctx context.Context) (*sql.Rows, error) {
+func (q *Query) execInsert(ctx context.Context, c QueryClient, r *QueryResult) (*sql.Rows, error) {
 	if len(q.values) == 0 {
 		return nil, errors.New("no values to insert")
 	}
@@ -180,7 +270,8 @@ func (q *Query) execInsert(ctx context.Context) (*sql.Rows, error) {
 	cols, vals := keysValues(q.values)
 	qs := insertQuery(q.tableName, cols, q.returning)
 
-	return q.client.QueryContext(ctx, qs, vals...)
+	r.stmt = q.statement(c, qs, vals, cols)
+	return c.QueryContext(withArgColumns(ctx, cols), qs, vals...)
 }
 
 // UPDATE
@@ -194,29 +285,33 @@ func UpdateQuery(c QueryClient, tableName string, attrs map[string]interface{})
 	}
 }
 
-func (q *Query) execUpdate(ctx context.Context) (int64, error) {
+func (q *Query) execUpdate(ctx context.Context, c QueryClient, r *QueryResult) (int64, error) {
 	cols, vals := keysValues(q.values)
-	where, whereVals := q.whereClause(1)
+	where, whereVals, whereCols := q.whereClause(1)
 
 	qs := updateQuery(q.tableName, cols, where, q.returning)
 
 	vals = append(vals, whereVals...)
-	result, err := q.client.ExecContext(ctx, qs, vals...)
+	argCols := append(cols, whereCols...)
+	r.stmt = q.statement(c, qs, vals, argCols)
+	result, err := c.ExecContext(withArgColumns(ctx, argCols), qs, vals...)
 	if err != nil {
 		return 0, err
 	}
 	return result.RowsAffected()
 }
 
-func (q *Query) execUpdateR(ctx context.Context) (*sql.Rows, error) {
+func (q *Query) execUpdateR(ctx context.Context, c QueryClient, r *QueryResult) (*sql.Rows, error) {
 	cols, vals := keysValues(q.values)
-	where, whereVals := q.whereClause(1)
+	where, whereVals, whereCols := q.whereClause(1)
 
 	qs := updateQuery(q.tableName, cols, where, q.returning)
 
 	vals = append(vals, whereVals...)
+	argCols := append(cols, whereCols...)
 
-	return q.client.QueryContext(ctx, qs, vals...)
+	r.stmt = q.statement(c, qs, vals, argCols)
+	return c.QueryContext(withArgColumns(ctx, argCols), qs, vals...)
 }
 
 // DELETE
@@ -229,36 +324,41 @@ func DeleteQuery(c QueryClient, tableName string) *Query {
 	}
 }
 
-func (q *Query) execDelete(ctx context.Context) (int64, error) {
-	where, vals := q.whereClause(1)
+func (q *Query) execDelete(ctx context.Context, c QueryClient, r *QueryResult) (int64, error) {
+	where, vals, argCols := q.whereClause(1)
 
 	qs := deleteQuery(q.tableName, where, q.returning)
 
-	result, err := q.client.ExecContext(ctx, qs, vals...)
+	r.stmt = q.statement(c, qs, vals, argCols)
+	result, err := c.ExecContext(withArgColumns(ctx, argCols), qs, vals...)
 	if err != nil {
 		return 0, err
 	}
 	return result.RowsAffected()
 }
 
-func (q *Query) execDeleteR(ctx context.Context) (*sql.Rows, error) {
-	where, vals := q.whereClause(1)
+func (q *Query) execDeleteR(ctx context.Context, c QueryClient, r *QueryResult) (*sql.Rows, error) {
+	where, vals, argCols := q.whereClause(1)
 
 	qs := deleteQuery(q.tableName, where, q.returning)
 
-	return q.client.QueryContext(ctx, qs, vals...)
+	r.stmt = q.statement(c, qs, vals, argCols)
+	return c.QueryContext(withArgColumns(ctx, argCols), qs, vals...)
 }
 
 // Helpers
 
 // i is the first $ number, startPos is the length of attributes in an update or insert query excluding where clause
-func (q *Query) whereClause(i int) (string, []interface{}) {
+//
+// the returned columns hold the column (or parameter name) of each value, empty for raw values
+func (q *Query) whereClause(i int) (string, []interface{}, []string) {
 	if i < 1 {
 		// the numbering starts at $1 not $0
 		i = 1
 	}
 
 	vals := make([]interface{}, 0, len(q.conditions))
+	cols := make([]string, 0, len(q.conditions))
 
 	b := strings.Builder{}
 
@@ -267,6 +367,15 @@ func (q *Query) whereClause(i int) (string, []interface{}) {
 
 	clauses := make([]string, 0, len(q.conditions))
 	for _, cond := range q.conditions {
+		if cond.named != nil {
+			clauses = append(clauses, cond.named.SQL(startPos))
+			vals = append(vals, cond.named.vals...)
+			cols = append(cols, cond.named.names...)
+
+			startPos += len(cond.named.vals)
+			continue
+		}
+
 		if cond.raw != "" {
 			n := len(cond.rawVals)
 			if n == 0 {
@@ -283,6 +392,7 @@ func (q *Query) whereClause(i int) (string, []interface{}) {
 			clauses = append(clauses, c)
 
 			vals = append(vals, cond.rawVals...)
+			cols = append(cols, make([]string, n)...)
 
 			startPos += n
 			continue
@@ -306,6 +416,9 @@ func (q *Query) whereClause(i int) (string, []interface{}) {
 			} else {
 				vals = append(vals, cond.val)
 			}
+			for len(cols) < len(vals) {
+				cols = append(cols, cond.col)
+			}
 			startPos = nextPos
 		}
 	}
@@ -316,8 +429,9 @@ func (q *Query) whereClause(i int) (string, []interface{}) {
 
 	// Ors
 	if len(q.ors) > 0 {
-		newWhere, newVals := addQueries(where, "OR", startPos, q.ors)
+		newWhere, newVals, newCols := addQueries(where, "OR", startPos, q.ors)
 		vals = append(vals, newVals...)
+		cols = append(cols, newCols...)
 
 		startPos += len(newVals)
 		where = newWhere
@@ -325,18 +439,41 @@ func (q *Query) whereClause(i int) (string, []interface{}) {
 
 	// Ands
 	if len(q.ands) > 0 {
-		newWhere, newVals := addQueries(where, "AND", startPos, q.ands)
+		newWhere, newVals, newCols := addQueries(where, "AND", startPos, q.ands)
 		vals = append(vals, newVals...)
+		cols = append(cols, newCols...)
 
 		startPos += len(newVals) //nolint:ineffassign
 		where = newWhere
 	}
 
-	return where, vals
+	return where, vals, cols
+}
+
+// buildErr returns the first error from building the query or its sub queries
+func (q *Query) buildErr() error {
+	if q.err != nil {
+		return q.err
+	}
+
+	for _, sub := range q.ors {
+		if err := sub.buildErr(); err != nil {
+			return err
+		}
+	}
+
+	for _, sub := range q.ands {
+		if err := sub.buildErr(); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func addQueries(where, separator string, startPos int, queries []*Query) (string, []interface{}) {
+func addQueries(where, separator string, startPos int, queries []*Query) (string, []interface{}, []string) {
 	var vals []interface{}
+	var cols []string
 	n := len(queries)
 
 	var clauses []string
@@ -348,7 +485,7 @@ func addQueries(where, separator string, startPos int, queries []*Query) (string
 	}
 
 	for _, q := range queries {
-		addWhere, addVals := q.whereClause(startPos)
+		addWhere, addVals, addCols := q.whereClause(startPos)
 		if addWhere == "" {
 			continue
 		}
@@ -356,13 +493,14 @@ func addQueries(where, separator string, startPos int, queries []*Query) (string
 		addWhere = strings.Join([]string{"(", addWhere, ")"}, "")
 		clauses = append(clauses, addWhere)
 		vals = append(vals, addVals...)
+		cols = append(cols, addCols...)
 
 		startPos += len(addVals)
 	}
 
 	padSep := strings.Join([]string{" ", separator, " "}, "")
 
-	return strings.Join(clauses, padSep), vals
+	return strings.Join(clauses, padSep), vals, cols
 }
 
 // Condition
@@ -373,6 +511,7 @@ type condition struct {
 	negative bool
 	raw      string
 	rawVals  []interface{}
+	named    *boundQuery
 }
 
 // returns the clause and the input int after incrementing by the amount of placeholders ($1) created
@@ -446,15 +585,56 @@ func condClauseVal(start int, negative bool) (string, int) {
 type QueryResult struct {
 	*sql.Rows
 	RowsAffected int64
+
+	timeout time.Duration
+	finish  func(error) error // ends the statement timeout transaction
+	release func()            // lets Shutdown proceed once the rows are closed
+	stmt    *statement
+}
+
+// error translates an error of the statement and wraps it in a QueryError
+func (r *QueryResult) error(err error) error {
+	return r.stmt.wrap(translateError(err, r.timeout))
+}
+
+// Close closes the rows and commits the transaction opened for a statement timeout, Shutdown waits for
+// results with rows until they are closed
+func (r *QueryResult) Close() error {
+	var err error
+	if r.Rows != nil {
+		err = r.Rows.Close()
+		if rowsErr := r.Rows.Err(); rowsErr != nil {
+			err = rowsErr
+		}
+	}
+
+	if r.release != nil {
+		release := r.release
+		r.release = nil
+		defer release()
+	}
+
+	if r.finish != nil {
+		finish := r.finish
+		r.finish = nil
+		err = finish(err)
+	}
+
+	return r.error(err)
 }
 
 // Pass in a pointer to a slice to convert the rows into
-func (r *QueryResult) Slice(ctx context.Context, slicePtr interface{}) error {
+func (r *QueryResult) Slice(ctx context.Context, slicePtr interface{}) (err error) {
 	if r.Rows == nil {
 		return errors.New("result rows is nil")
 	}
 
-	defer r.Close()
+	// closing commits the statement timeout transaction, its error counts once the rows were read
+	defer func() {
+		if closeErr := r.Close(); err == nil {
+			err = closeErr
+		}
+	}()
 
 	slicePtrType := reflect.TypeOf(slicePtr)
 
@@ -482,22 +662,26 @@ func (r *QueryResult) Slice(ctx context.Context, slicePtr interface{}) error {
 	}
 
 	if scanAsStruct(sliceElemType) {
-		return scanStructs(r.Rows, baseType, sliceElemType, outSliceVal)
+		return r.error(scanStructs(r.Rows, baseType, sliceElemType, outSliceVal))
 	}
 
-	return scanNatives(r.Rows, baseType, sliceElemType, outSliceVal)
+	return r.error(scanNatives(r.Rows, baseType, sliceElemType, outSliceVal))
 }
 
 // send in the pointer to scan a single value from a single row
-func (r *QueryResult) Scan(ctx context.Context, ptr interface{}) error {
+func (r *QueryResult) Scan(ctx context.Context, ptr interface{}) (err error) {
 	if r.Rows == nil {
 		return errors.New("result rows is nil")
 	}
-	defer r.Close()
+	defer func() {
+		if closeErr := r.Close(); err == nil {
+			err = closeErr
+		}
+	}()
 
 	if !r.Rows.Next() {
 		if r.Rows.Err() != nil {
-			return r.Rows.Err()
+			return r.error(r.Rows.Err())
 		}
 
 		return ErrNoRows
@@ -511,7 +695,7 @@ func (r *QueryResult) Scan(ctx context.Context, ptr interface{}) error {
 	}
 
 	if !scanAsStruct(ptrType) {
-		return r.Rows.Scan(ptr)
+		return r.error(r.Rows.Scan(ptr))
 	}
 
 	cols, err := r.Rows.Columns()
@@ -531,5 +715,5 @@ func (r *QueryResult) Scan(ctx context.Context, ptr interface{}) error {
 		vals = structVals(v, cols)
 	}
 
-	return r.Rows.Scan(vals...)
+	return r.error(r.Rows.Scan(vals...))
 }
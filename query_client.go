@@ -26,7 +26,7 @@ func Insert(ctx context.Context, c QueryClient, v Model, cols ...string) error {
 
 	mh := &ModelHelper{v}
 
-	result, err := InsertQuery(c, v.TableName(), mh.Attributes(cols...)).Exec(ctx)
+	result, err := InsertQuery(c, modelTableName(v), mh.Attributes(cols...)).Exec(ctx)
 	if err != nil {
 		return err
 	}
@@ -44,7 +44,7 @@ func Update(ctx context.Context, c QueryClient, v Model, cols ...string) error {
 		return errors.New("cannot update with id of 0")
 	}
 
-	_, err = UpdateQuery(c, v.TableName(), mh.Attributes(cols...)).Where(Attrs{"id": id}).Exec(ctx)
+	_, err = UpdateQuery(c, modelTableName(v), mh.Attributes(cols...)).Where(Attrs{"id": id}).Exec(ctx)
 
 	return err
 }
@@ -60,7 +60,7 @@ func Delete(ctx context.Context, c QueryClient, v Model) (int64, error) {
 		return 0, errors.New("cannot delete with id of 0")
 	}
 
-	result, err := DeleteQuery(c, v.TableName()).Where(Attrs{"id": id}).Exec(ctx)
+	result, err := DeleteQuery(c, modelTableName(v)).Where(Attrs{"id": id}).Exec(ctx)
 
 	return result.RowsAffected, err
 }
@@ -88,18 +88,59 @@ func DeleteAll(c QueryClient, table string) *Query {
 }
 
 func RawSelect(ctx context.Context, c QueryClient, outSlicePtr interface{}, q string, args ...interface{}) error {
-	r, err := RawQuery(ctx, c, q, args...)
+	r, err := rawQuery(ctx, c, usesReplica(ctx), q, args...)
 	if err != nil {
 		return err
 	}
 	return r.Slice(ctx, outSlicePtr)
 }
 
+// Passing Named(params) as the only arg uses :name or @name parameters, see Named
 func RawQuery(ctx context.Context, c QueryClient, q string, args ...interface{}) (*QueryResult, error) {
+	return rawQuery(ctx, c, false, q, args...)
+}
+
+// rawQuery runs q on a replica when read is true and c has one, see WithReplicas
+func rawQuery(ctx context.Context, c QueryClient, read bool, q string, args ...interface{}) (*QueryResult, error) {
 	var r QueryResult
+	var argCols []string
+
+	if na, ok := namedArgs(args); ok {
+		bq, err := bindNamed(q, na)
+		if err != nil {
+			return &r, err
+		}
+
+		q, args, argCols = bq.SQL(1), bq.vals, bq.names
+		ctx = withArgColumns(ctx, argCols)
+	}
+
+	c = resolveClient(ctx, c)
+	if read {
+		c = readClient(ctx, c)
+	}
+
+	// the client's default applies to its connections, it is only recorded for errors
+	if st, ok := c.(statementTimeouter); ok {
+		r.timeout = st.StatementTimeout()
+	}
+
+	r.stmt = newStatement(c, inferOperation(q), "", q, args, argCols)
+
+	release, err := holdRows(c)
+	if err != nil {
+		return &r, err
+	}
+
 	rows, err := c.QueryContext(ctx, q, args...)
 	r.Rows = rows
-	return &r, err
+	if err != nil {
+		release()
+	} else {
+		r.release = release
+	}
+
+	return &r, r.error(err)
 }
 
 // Returning Queries
@@ -113,7 +154,7 @@ func InsertReturning(ctx context.Context, c QueryClient, v Model, cols ...string
 
 	mh := &ModelHelper{v}
 
-	return InsertQuery(c, v.TableName(), mh.Attributes(cols...)).Returning("*").Scan(ctx, v)
+	return InsertQuery(c, modelTableName(v), mh.Attributes(cols...)).Returning("*").Scan(ctx, v)
 }
 
 func UpdateReturning(ctx context.Context, c QueryClient, v Model, cols ...string) error {
@@ -133,7 +174,7 @@ func UpdateReturning(ctx context.Context, c QueryClient, v Model, cols ...string
 		return errors.New("cannot update with id of 0")
 	}
 
-	q := UpdateQuery(c, v.TableName(), mh.Attributes(cols...)).Where(Attrs{"id": id})
+	q := UpdateQuery(c, modelTableName(v), mh.Attributes(cols...)).Where(Attrs{"id": id})
 	return q.Returning("*").Scan(ctx, v)
 }
 
@@ -152,5 +193,5 @@ func DeleteReturning(ctx context.Context, c QueryClient, v Model) error {
 		return errors.New("cannot delete with id of 0")
 	}
 
-	return DeleteQuery(c, v.TableName()).Where(Attrs{"id": id}).Returning("*").Scan(ctx, v)
+	return DeleteQuery(c, modelTableName(v)).Where(Attrs{"id": id}).Returning("*").Scan(ctx, v)
 }
@@ -0,0 +1,151 @@
+package psql
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// maxArgSummaryLen is the length after which argument values are truncated in QueryError messages
+const maxArgSummaryLen = 64
+
+// QueryError is returned when a statement run by a Query, the model helpers or RawQuery fails. It unwraps
+// to the underlying error, e.g. a TimeoutError, an *Error or the *pq.Error itself.
+type QueryError struct {
+	Op    Operation
+	Table string // empty for raw queries
+	SQL   string
+
+	// Args are the statement's arguments with EncryptableString values and values bound to redacted
+	// columns replaced by Redacted, see WithRedactedColumns
+	Args []interface{}
+
+	// Position is the 1-based character position in SQL the postgres error points at, 0 if none
+	Position int
+	Hint     string
+	Detail   string
+
+	Err error
+}
+
+func (e *QueryError) Error() string {
+	var b StringsBuilder
+	b.WriteStrings(string(e.Op))
+	if e.Table != "" {
+		b.WriteStrings(" on ", e.Table)
+	}
+	b.WriteStrings(": ", e.Err.Error(), " (sql: ", e.SQL)
+	if len(e.Args) > 0 {
+		b.WriteStrings(", args: ", e.ArgsSummary())
+	}
+	b.WriteStrings(")")
+
+	return b.String()
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// ArgsSummary formats the redacted arguments as `$1="a", $2=5` truncating long values
+func (e *QueryError) ArgsSummary() string {
+	parts := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		var s string
+		switch v := arg.(type) {
+		case string:
+			s = strconv.Quote(truncate(v))
+		case []byte:
+			s = fmt.Sprintf("<%d bytes>", len(v))
+		default:
+			s = truncate(fmt.Sprintf("%v", v))
+		}
+
+		parts[i] = fmt.Sprintf("$%d=%s", i+1, s)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func truncate(s string) string {
+	if len(s) <= maxArgSummaryLen {
+		return s
+	}
+
+	return s[:maxArgSummaryLen] + "..."
+}
+
+// WithRedactedColumns redacts the values bound to the given columns (or named parameters) from QueryErrors.
+// EncryptableString values are always redacted.
+func WithRedactedColumns(cols ...string) ClientOption {
+	return func(c *Client) {
+		c.redactColumns = append(c.redactColumns, cols...)
+	}
+}
+
+// statement is the SQL run by a Query or RawQuery, kept to describe its errors
+type statement struct {
+	op         Operation
+	table      string
+	sql        string
+	args       []interface{}
+	argColumns []string
+	redact     []string
+}
+
+func newStatement(c QueryClient, op Operation, table, sql string, args []interface{}, argCols []string) *statement {
+	return &statement{
+		op:         op,
+		table:      table,
+		sql:        sql,
+		args:       args,
+		argColumns: argCols,
+		redact:     redactedColumns(c),
+	}
+}
+
+// wrap returns err in a QueryError describing the statement, it is safe to call on a nil statement
+func (s *statement) wrap(err error) error {
+	if err == nil || s == nil || errors.Is(err, ErrNoRows) {
+		return err
+	}
+
+	var qe *QueryError
+	if errors.As(err, &qe) {
+		return err
+	}
+
+	qe = &QueryError{
+		Op:    s.op,
+		Table: s.table,
+		SQL:   s.sql,
+		Args:  redactArgs(s.args, s.argColumns, s.redact),
+		Err:   err,
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		qe.Position, _ = strconv.Atoi(pqErr.Position)
+		qe.Hint = pqErr.Hint
+		qe.Detail = pqErr.Detail
+	}
+
+	return qe
+}
+
+// redactedColumns returns the columns whose values the client redacts
+func redactedColumns(c QueryClient) []string {
+	switch c := c.(type) {
+	case *Client:
+		return c.redactColumns
+	case *Tx:
+		if c.client != nil {
+			return c.client.redactColumns
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package psql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatement_Wrap(t *testing.T) {
+	c := NewClient(nil, WithRedactedColumns("string_field"))
+
+	pqErr := &pq.Error{
+		Code:     "23505",
+		Message:  "duplicate key value violates unique constraint",
+		Detail:   "Key (string_field)=(secret) already exists.",
+		Hint:     "some hint",
+		Position: "13",
+	}
+
+	s := newStatement(c, OpInsert, "mock_models", `INSERT INTO "mock_models" ("int_field", "string_field") VALUES ($1, $2)`,
+		[]interface{}{1, "secret"}, []string{"int_field", "string_field"})
+
+	err := s.wrap(wrapError(pqErr))
+
+	var qe *QueryError
+	require.True(t, errors.As(err, &qe))
+	require.Equal(t, OpInsert, qe.Op)
+	require.Equal(t, "mock_models", qe.Table)
+	require.Equal(t, s.sql, qe.SQL)
+	require.Equal(t, []interface{}{1, Redacted}, qe.Args)
+	require.Equal(t, 13, qe.Position)
+	require.Equal(t, "some hint", qe.Hint)
+	require.Equal(t, pqErr.Detail, qe.Detail)
+
+	require.True(t, errors.Is(err, ErrUniqueViolation))
+
+	var unwrapped *pq.Error
+	require.True(t, errors.As(err, &unwrapped))
+	require.Equal(t, pqErr, unwrapped)
+
+	msg := err.Error()
+	require.True(t, strings.Contains(msg, s.sql), msg)
+	require.True(t, strings.Contains(msg, `$1=1, $2="[REDACTED]"`), msg)
+	require.True(t, strings.Contains(msg, "duplicate key"), msg)
+
+	// not wrapped twice
+	require.Equal(t, err, s.wrap(err))
+
+	var nilStmt *statement
+	require.Equal(t, error(pqErr), nilStmt.wrap(pqErr))
+	require.Nil(t, s.wrap(nil))
+	require.Equal(t, ErrNoRows, s.wrap(ErrNoRows))
+}
+
+func TestQueryError_ArgsSummary(t *testing.T) {
+	e := &QueryError{Args: []interface{}{strings.Repeat("a", 100), []byte("abc"), nil, 2.5}}
+	require.Equal(t, `$1="`+strings.Repeat("a", 64)+`...", $2=<3 bytes>, $3=<nil>, $4=2.5`, e.ArgsSummary())
+}
@@ -118,6 +118,18 @@ func TestQuery_Test(t *testing.T) {
 		t.Fatalf("wrong number selected, expected 1 and got %v", len(models))
 	}
 
+	//// raw where with named parameters
+
+	models = nil
+
+	if err := SelectQuery(c, tableName, "id").WhereRaw("coalesce(string_field, '') NOT LIKE 'abc%' AND int_field = :int AND int_field < @int + 1", Named(Attrs{"int": 3})).Slice(ctx, &models); err != nil {
+		t.Fatalf("select failed with error %v", err)
+	}
+
+	if len(models) != 1 {
+		t.Fatalf("wrong number selected, expected 1 and got %v", len(models))
+	}
+
 	//// range
 
 	models = nil
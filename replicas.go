@@ -0,0 +1,234 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReplicaPolicy selects the replica reads are sent to
+type ReplicaPolicy int
+
+const (
+	ReplicaRoundRobin ReplicaPolicy = iota // replicas in turn
+	ReplicaLeastBusy                       // the replica with the fewest connections in use
+)
+
+// DefaultReplicaCheckInterval is how often the health of replicas is checked
+var DefaultReplicaCheckInterval = 5 * time.Second
+
+// replica is a Client connected to a read replica of the primary
+type replica struct {
+	*Client
+	healthy int32 // accessed atomically, 1 unless the last Status check found the replica unhealthy
+}
+
+func (r *replica) isHealthy() bool {
+	return atomic.LoadInt32(&r.healthy) == 1
+}
+
+// replicaSet holds the replicas of a Client and checks their health between Start and Close
+type replicaSet struct {
+	dsns     []string
+	policy   ReplicaPolicy
+	replicas []*replica
+	next     uint32 // accessed atomically
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// WithReplicas sends reads of Select queries to read replicas connected to with the given DSNs while
+// everything else, including all Tx work, goes to the primary. RawSelect only reads from replicas with a
+// context from UseReplica since its SQL may write or lock rows, e.g. INSERT ... RETURNING or
+// SELECT ... FOR UPDATE, which fail on a standby. Replicas failing their health check are skipped, when
+// none is healthy reads go to the primary. See UsePrimary for read-your-writes.
+func WithReplicas(policy ReplicaPolicy, dsns ...string) ClientOption {
+	return func(c *Client) {
+		c.replicas = &replicaSet{dsns: dsns, policy: policy}
+	}
+}
+
+type usePrimaryKey struct{}
+
+// UsePrimary returns a context whose reads go to the primary, e.g. to read data just written
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, usePrimaryKey{}, true)
+}
+
+type useReplicaKey struct{}
+
+// UseReplica returns a context whose RawSelect calls read from a replica, their SQL must only read
+func UseReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, useReplicaKey{}, true)
+}
+
+func usesReplica(ctx context.Context) bool {
+	replica, _ := ctx.Value(useReplicaKey{}).(bool)
+	return replica
+}
+
+// open connects to the replicas and starts checking their health
+func (rs *replicaSet) open(driverName string, primary *Client) error {
+	rs.replicas = nil
+	for _, dsn := range rs.dsns {
+		dsn, err := withDSNStatementTimeout(dsn, primary.statementTimeout)
+		if err != nil {
+			rs.close()
+			return fmt.Errorf("invalid postgres replica connection string: %w", err)
+		}
+
+		db, err := sql.Open(driverName, dsn)
+		if err != nil {
+			rs.close()
+			return fmt.Errorf("unable to open connection to postgres replica: %w", err)
+		}
+		primary.pool.apply(db)
+
+		rs.replicas = append(rs.replicas, &replica{
+			Client: &Client{
+				DB:               db,
+				connStr:          dsn,
+				statementTimeout: primary.statementTimeout,
+				hooks:            primary.hooks,
+				redactColumns:    primary.redactColumns,
+				work:             primary.work,
+				healthThresholds: primary.healthThresholds,
+			},
+			healthy: 1,
+		})
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rs.cancel, rs.done = cancel, make(chan struct{})
+
+	go func(done chan struct{}) {
+		defer close(done)
+
+		t := time.NewTicker(DefaultReplicaCheckInterval)
+		defer t.Stop()
+
+		for {
+			rs.checkHealth(ctx)
+
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}(rs.done)
+
+	return nil
+}
+
+func (rs *replicaSet) checkHealth(ctx context.Context) {
+	for _, r := range rs.replicas {
+		ctx, cancel := context.WithTimeout(ctx, DefaultReplicaCheckInterval)
+
+		var healthy int32
+		if r.Status(ctx) == nil {
+			healthy = 1
+		}
+		cancel()
+
+		atomic.StoreInt32(&r.healthy, healthy)
+	}
+}
+
+// close stops the health checks and closes the replicas' connections
+func (rs *replicaSet) close() error {
+	rs.mu.Lock()
+	if rs.cancel != nil {
+		rs.cancel()
+		<-rs.done
+		rs.cancel, rs.done = nil, nil
+	}
+	rs.mu.Unlock()
+
+	var err error
+	for _, r := range rs.replicas {
+		if closeErr := r.DB.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
+// pick returns a healthy replica according to the policy, nil if there is none
+func (rs *replicaSet) pick() *replica {
+	n := len(rs.replicas)
+	if n == 0 {
+		return nil
+	}
+
+	if rs.policy == ReplicaLeastBusy {
+		var best *replica
+		bestInUse := 0
+		for _, r := range rs.replicas {
+			if !r.isHealthy() {
+				continue
+			}
+
+			if inUse := r.DB.Stats().InUse; best == nil || inUse < bestInUse {
+				best, bestInUse = r, inUse
+			}
+		}
+
+		return best
+	}
+
+	start := int(atomic.AddUint32(&rs.next, 1) - 1)
+	for i := 0; i < n; i++ {
+		if r := rs.replicas[(start+i)%n]; r.isHealthy() {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// readClient returns the client to read from, a replica when c is a *Client with a healthy replica
+// unless ctx asks for the primary
+func readClient(ctx context.Context, c QueryClient) QueryClient {
+	client, ok := c.(*Client)
+	if !ok || client.replicas == nil {
+		return c
+	}
+
+	if primary, _ := ctx.Value(usePrimaryKey{}).(bool); primary {
+		return c
+	}
+
+	if r := client.replicas.pick(); r != nil {
+		return r.Client
+	}
+
+	return c
+}
+
+// withDSNStatementTimeout returns dsn setting the client's default statement timeout unless it sets one
+func withDSNStatementTimeout(dsn string, d time.Duration) (string, error) {
+	if d <= 0 {
+		return dsn, nil
+	}
+
+	cfg, err := ParseConfig(dsn)
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := cfg.RuntimeParams["statement_timeout"]; !ok {
+		cfg.StatementTimeout = d
+	}
+
+	return cfg.connString(), nil
+}
@@ -0,0 +1,133 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestReplicas(t *testing.T, policy ReplicaPolicy, n int) *replicaSet {
+	rs := &replicaSet{policy: policy}
+	for i := 0; i < n; i++ {
+		db, err := sql.Open("postgres", "")
+		require.Nil(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+
+		rs.replicas = append(rs.replicas, &replica{Client: &Client{DB: db}, healthy: 1})
+	}
+
+	return rs
+}
+
+func TestReplicaSet_Pick(t *testing.T) {
+	rs := newTestReplicas(t, ReplicaRoundRobin, 3)
+
+	require.Equal(t, rs.replicas[0], rs.pick())
+	require.Equal(t, rs.replicas[1], rs.pick())
+	require.Equal(t, rs.replicas[2], rs.pick())
+	require.Equal(t, rs.replicas[0], rs.pick())
+
+	// unhealthy replicas are skipped
+	rs.replicas[1].healthy = 0
+	require.Equal(t, rs.replicas[2], rs.pick())
+	require.Equal(t, rs.replicas[2], rs.pick())
+	require.Equal(t, rs.replicas[0], rs.pick())
+
+	rs.replicas[0].healthy, rs.replicas[2].healthy = 0, 0
+	require.Nil(t, rs.pick())
+
+	rs = newTestReplicas(t, ReplicaLeastBusy, 2)
+	require.Equal(t, rs.replicas[0], rs.pick())
+
+	rs.replicas[0].healthy = 0
+	require.Equal(t, rs.replicas[1], rs.pick())
+
+	require.Nil(t, (&replicaSet{}).pick())
+}
+
+func TestReadClient(t *testing.T) {
+	c := NewClient(nil)
+	c.replicas = newTestReplicas(t, ReplicaRoundRobin, 1)
+	ctx := context.Background()
+
+	require.Equal(t, c.replicas.replicas[0].Client, readClient(ctx, c))
+	require.Equal(t, c, readClient(UsePrimary(ctx), c))
+
+	tx := &Tx{client: c}
+	require.Equal(t, tx, readClient(ctx, tx))
+
+	// reads in the ambient transaction stay on the primary
+	txCtx := ContextWithTx(ctx, tx)
+	require.Equal(t, tx, readClient(txCtx, resolveClient(txCtx, c)))
+
+	// clients without replicas read from themselves
+	other := NewClient(nil)
+	require.Equal(t, other, readClient(ctx, other))
+}
+
+func TestClient_Replicas(t *testing.T) {
+	// the replica is the primary itself connected to separately with another application_name
+	dsn := "application_name=replica"
+	if u := os.Getenv("DATABASE_URL"); u != "" {
+		sep := "?"
+		if strings.Contains(u, "?") {
+			sep = "&"
+		}
+		dsn = u + sep + dsn
+	}
+
+	c := NewClient(nil, WithReplicas(ReplicaRoundRobin, dsn))
+
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+
+	if _, err := c.Exec(modelsTable); err != nil {
+		t.Fatalf("failed to create table %v", err)
+	}
+
+	defer func() {
+		_, _ = c.Exec("drop table mock_models")
+		_ = c.Close()
+	}()
+
+	ctx := context.Background()
+	require.Nil(t, c.Insert(ctx, &MockModel{IntField: 1}))
+
+	onReplica := "current_setting('application_name') = 'replica'"
+
+	var results []*MockModel
+	require.Nil(t, c.Select("mock_models").WhereRaw(onReplica).Slice(ctx, &results))
+	require.Len(t, results, 1)
+
+	results = nil
+	require.Nil(t, c.Select("mock_models").WhereRaw(onReplica).Slice(UsePrimary(ctx), &results))
+	require.Len(t, results, 0)
+
+	// raw selects read from the primary unless asked for a replica
+	var names []string
+	require.Nil(t, c.RawSelect(ctx, &names, "select current_setting('application_name')"))
+	require.Len(t, names, 1)
+	require.NotEqual(t, "replica", names[0])
+
+	names = nil
+	require.Nil(t, c.RawSelect(UseReplica(ctx), &names, "select current_setting('application_name')"))
+	require.Equal(t, []string{"replica"}, names)
+
+	names = nil
+	r, err := c.RawQuery(ctx, "select current_setting('application_name')")
+	require.Nil(t, err)
+	require.Nil(t, r.Slice(ctx, &names))
+	require.Len(t, names, 1)
+	require.NotEqual(t, "replica", names[0])
+
+	err = c.RunInTransaction(ctx, func(ctx context.Context, tx *Tx) error {
+		return c.Select("mock_models").WhereRaw(onReplica).Slice(ctx, &results)
+	}, nil)
+	require.Nil(t, err)
+	require.Len(t, results, 0)
+}
@@ -0,0 +1,112 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// DefaultRetryPolicy is used for the zero fields of the RetryPolicy passed to RunInTransactionWithRetry
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   10 * time.Millisecond,
+	MaxDelay:    time.Second,
+}
+
+// RetryPolicy controls how transactions failing with a serialization failure (40001) or a deadlock (40P01)
+// are retried. The delay before each retry doubles from BaseDelay up to MaxDelay with random jitter of up
+// to half the delay.
+type RetryPolicy struct {
+	MaxAttempts int // including the first attempt
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var (
+	jitterMu   sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	if p.MaxDelay < p.BaseDelay {
+		p.MaxDelay = p.BaseDelay
+	}
+
+	return p
+}
+
+// backoff returns the delay after the given failed attempt, rnd returns a number in [0, n)
+func (p RetryPolicy) backoff(attempt int, rnd func(n int64) int64) time.Duration {
+	d := p.MaxDelay
+	if shift := uint(attempt - 1); shift < 32 && p.BaseDelay<<shift < p.MaxDelay {
+		d = p.BaseDelay << shift
+	}
+
+	half := d / 2
+	return d - half + time.Duration(rnd(int64(half)+1))
+}
+
+func jitter(n int64) int64 {
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+
+	return jitterRand.Int63n(n)
+}
+
+// IsRetryable reports whether err is a serialization failure or a deadlock, transactions failing with them
+// can succeed when run again
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrSerializationFailure) || errors.Is(err, ErrDeadlock)
+}
+
+// RunInTransactionWithRetry runs f in a transaction like RunInTransaction and runs it again in a new
+// transaction while it fails with a serialization failure or a deadlock, see RetryPolicy. f must be safe
+// to run several times, panics are not retried.
+//
+// It stops retrying when ctx is done or its deadline would pass before the next attempt and returns the
+// error of the last attempt. Each attempt is reported to the client's hooks implementing TxHook.
+func (c *Client) RunInTransactionWithRetry(ctx context.Context, f func(context.Context, *Tx) error, opts *sql.TxOptions, p RetryPolicy) error {
+	p = p.withDefaults()
+
+	for attempt := 1; ; attempt++ {
+		e := &TxEvent{Attempt: attempt}
+		err := c.runTransaction(ctx, f, c.txOptions(opts), e)
+
+		retry := err != nil && attempt < p.MaxAttempts && IsRetryable(err)
+
+		var delay time.Duration
+		if retry {
+			delay = p.backoff(attempt, jitter)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+				retry = false
+			}
+		}
+
+		e.Retrying = retry
+		c.afterTx(ctx, e)
+
+		if !retry {
+			return err
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
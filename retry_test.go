@@ -0,0 +1,153 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+type txRecorder struct {
+	HookFuncs
+	events []TxEvent
+}
+
+func (r *txRecorder) AfterTx(ctx context.Context, e *TxEvent) {
+	r.events = append(r.events, *e)
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}.withDefaults()
+	require.Equal(t, DefaultRetryPolicy.MaxAttempts, p.MaxAttempts)
+
+	none := func(int64) int64 { return 0 }
+	most := func(n int64) int64 { return n - 1 }
+
+	require.Equal(t, 5*time.Millisecond, p.backoff(1, none))
+	require.Equal(t, 10*time.Millisecond, p.backoff(1, most))
+	require.Equal(t, 10*time.Millisecond, p.backoff(2, none))
+	require.Equal(t, 20*time.Millisecond, p.backoff(3, none))
+
+	// capped
+	require.Equal(t, 25*time.Millisecond, p.backoff(4, none))
+	require.Equal(t, 50*time.Millisecond, p.backoff(100, most))
+}
+
+func TestIsRetryable(t *testing.T) {
+	require.True(t, IsRetryable(wrapError(&pq.Error{Code: "40001"})))
+	require.True(t, IsRetryable(&QueryError{Err: wrapError(&pq.Error{Code: "40P01"})}))
+	require.False(t, IsRetryable(wrapError(&pq.Error{Code: "23505"})))
+	require.False(t, IsRetryable(errors.New("some error")))
+	require.False(t, IsRetryable(nil))
+}
+
+func TestClient_RunInTransactionWithRetry(t *testing.T) {
+	serializationErr := wrapError(&pq.Error{Code: "40001"})
+	p := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	tcs := map[string]struct {
+		Run func(*testing.T, *Client, *txRecorder)
+	}{
+		"succeeds after retries": {
+			Run: func(t *testing.T, c *Client, r *txRecorder) {
+				ctx := context.Background()
+
+				attempts := 0
+				err := c.RunInTransactionWithRetry(ctx, func(ctx context.Context, tx *Tx) error {
+					attempts++
+					if err := tx.Insert(ctx, &MockModel{IntField: attempts}); err != nil {
+						return err
+					}
+
+					if attempts < 3 {
+						return serializationErr
+					}
+					return nil
+				}, nil, p)
+				require.Nil(t, err)
+				require.Equal(t, 3, attempts)
+
+				// only the last attempt was committed
+				var results []int
+				err = c.Select("mock_models", "int_field").Slice(ctx, &results)
+				require.Nil(t, err)
+				require.Equal(t, []int{3}, results)
+
+				require.Len(t, r.events, 3)
+				require.Equal(t, 1, r.events[0].Attempt)
+				require.True(t, r.events[0].Retrying)
+				require.Equal(t, TxRolledBack, r.events[0].Outcome)
+				require.Equal(t, serializationErr, r.events[0].Err)
+				require.Equal(t, TxCommitted, r.events[2].Outcome)
+				require.Equal(t, 3, r.events[2].Attempt)
+				require.False(t, r.events[2].Retrying)
+				require.Nil(t, r.events[2].Err)
+			},
+		},
+		"max attempts": {
+			Run: func(t *testing.T, c *Client, r *txRecorder) {
+				attempts := 0
+				err := c.RunInTransactionWithRetry(context.Background(), func(ctx context.Context, tx *Tx) error {
+					attempts++
+					return serializationErr
+				}, nil, p)
+				require.True(t, errors.Is(err, ErrSerializationFailure))
+				require.Equal(t, 3, attempts)
+				require.Len(t, r.events, 3)
+			},
+		},
+		"other errors are not retried": {
+			Run: func(t *testing.T, c *Client, r *txRecorder) {
+				someErr := errors.New("some error")
+
+				attempts := 0
+				err := c.RunInTransactionWithRetry(context.Background(), func(ctx context.Context, tx *Tx) error {
+					attempts++
+					return someErr
+				}, nil, p)
+				require.Equal(t, someErr, err)
+				require.Equal(t, 1, attempts)
+			},
+		},
+		"deadline": {
+			Run: func(t *testing.T, c *Client, r *txRecorder) {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+
+				attempts := 0
+				err := c.RunInTransactionWithRetry(ctx, func(ctx context.Context, tx *Tx) error {
+					attempts++
+					return serializationErr
+				}, &sql.TxOptions{Isolation: sql.LevelSerializable}, RetryPolicy{MaxAttempts: 10, BaseDelay: time.Second})
+				require.True(t, errors.Is(err, ErrSerializationFailure))
+				require.Equal(t, 1, attempts)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			r := &txRecorder{}
+			c := NewClient(nil, WithHooks(r))
+
+			if err := c.Start(""); err != nil {
+				t.Fatalf("Failed to start %v", err)
+			}
+
+			if _, err := c.Exec(modelsTable); err != nil {
+				t.Fatalf("failed to create table %v", err)
+			}
+
+			defer func() {
+				_, _ = c.Exec("drop table mock_models")
+				_ = c.Close()
+			}()
+
+			tc.Run(t, c, r)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// savepointMark is the number of OnCommit and OnRollback callbacks registered when a savepoint was established
+type savepointMark struct {
+	name      string
+	callbacks int
+}
+
+// Savepoint establishes a savepoint named name in the transaction
+func (tx *Tx) Savepoint(ctx context.Context, name string) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+Quote(name)); err != nil {
+		return err
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	tx.marks = append(tx.marks, savepointMark{name: name, callbacks: len(tx.callbacks)})
+	return nil
+}
+
+// RollbackTo rolls back the changes made since the savepoint was established and discards the callbacks
+// registered since, the savepoint remains
+func (tx *Tx) RollbackTo(ctx context.Context, name string) error {
+	if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+Quote(name)); err != nil {
+		return err
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if i := tx.markIndex(name); i >= 0 {
+		tx.callbacks = tx.callbacks[:tx.marks[i].callbacks]
+		// later savepoints are destroyed
+		tx.marks = tx.marks[:i+1]
+	}
+
+	return nil
+}
+
+// Release destroys the savepoint keeping the changes made since it was established
+func (tx *Tx) Release(ctx context.Context, name string) error {
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+Quote(name)); err != nil {
+		return err
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if i := tx.markIndex(name); i >= 0 {
+		tx.marks = tx.marks[:i]
+	}
+
+	return nil
+}
+
+// markIndex returns the index of the innermost savepoint named name, -1 if there is none, tx.mu must be held
+func (tx *Tx) markIndex(name string) int {
+	for i := len(tx.marks) - 1; i >= 0; i-- {
+		if tx.marks[i].name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// RunInSavepoint runs f in a savepoint of the transaction. The changes made by f are rolled back to the
+// savepoint if f returns an error or panics and kept otherwise, the transaction itself is not ended.
+// Calls can be nested.
+func (tx *Tx) RunInSavepoint(ctx context.Context, f func(context.Context, *Tx) error) error {
+	tx.mu.Lock()
+	tx.savepoints++
+	name := fmt.Sprintf("psql_savepoint_%d", tx.savepoints)
+	tx.mu.Unlock()
+
+	defer func() {
+		tx.mu.Lock()
+		tx.savepoints--
+		tx.mu.Unlock()
+	}()
+
+	if err := tx.Savepoint(ctx, name); err != nil {
+		return err
+	}
+
+	var ranFunc bool
+	defer func() {
+		if !ranFunc {
+			// panicked in f still need to rollback to the savepoint
+			_ = tx.rollbackToAndRelease(context.Background(), name)
+		}
+	}()
+
+	err := f(ContextWithTx(ctx, tx), tx)
+	ranFunc = true
+	if err != nil {
+		if rbErr := tx.rollbackToAndRelease(ctx, name); rbErr != nil {
+			return &RollbackError{Err: err, RollbackErr: rbErr}
+		}
+		return err
+	}
+
+	return tx.Release(ctx, name)
+}
+
+// rollbackToAndRelease rolls back to the savepoint and destroys it so sibling calls of RunInSavepoint
+// don't stack savepoints of the same name
+func (tx *Tx) rollbackToAndRelease(ctx context.Context, name string) error {
+	if err := tx.RollbackTo(ctx, name); err != nil {
+		return err
+	}
+
+	return tx.Release(ctx, name)
+}
+
+// RunInTransaction runs f in a new transaction when c is a *Client or in a savepoint when c is already
+// a *Tx, or ctx carries a transaction of c, so that f is all or nothing either way.
+func RunInTransaction(ctx context.Context, c QueryClient, f func(context.Context, *Tx) error) error {
+	switch c := resolveClient(ctx, c).(type) {
+	case *Tx:
+		return c.RunInSavepoint(ctx, f)
+	case *Client:
+		return c.RunInTransaction(ctx, f, nil)
+	default:
+		return errors.New("transactions are only supported on *Client and *Tx")
+	}
+}
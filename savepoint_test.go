@@ -0,0 +1,145 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTx_Savepoints(t *testing.T) {
+	intFields := func(t *testing.T, c QueryClient) []int {
+		var results []int
+		err := Select(c, "mock_models", "int_field").OrderBy("int_field ASC").Slice(context.Background(), &results)
+		require.Nil(t, err)
+		return results
+	}
+
+	tcs := map[string]struct {
+		Run func(*testing.T, *Client)
+	}{
+		"savepoint rollback and release": {
+			Run: func(t *testing.T, c *Client) {
+				ctx := context.Background()
+
+				tx, err := c.BeginTx(ctx, nil)
+				require.Nil(t, err)
+
+				require.Nil(t, tx.Insert(ctx, &MockModel{IntField: 1}))
+				require.Nil(t, tx.Savepoint(ctx, "first"))
+				require.Nil(t, tx.Insert(ctx, &MockModel{IntField: 2}))
+				require.Nil(t, tx.RollbackTo(ctx, "first"))
+				require.Nil(t, tx.Insert(ctx, &MockModel{IntField: 3}))
+				require.Nil(t, tx.Release(ctx, "first"))
+
+				require.NotNil(t, tx.Release(ctx, "first"))
+				require.Nil(t, tx.Rollback())
+
+				require.Len(t, intFields(t, c), 0)
+			},
+		},
+		"nested": {
+			Run: func(t *testing.T, c *Client) {
+				ctx := context.Background()
+				someErr := errors.New("some error")
+
+				err := RunInTransaction(ctx, c, func(ctx context.Context, tx *Tx) error {
+					require.Nil(t, tx.Insert(ctx, &MockModel{IntField: 1}))
+
+					err := RunInTransaction(ctx, tx, func(ctx context.Context, tx *Tx) error {
+						require.Nil(t, tx.Insert(ctx, &MockModel{IntField: 2}))
+
+						err := tx.RunInSavepoint(ctx, func(ctx context.Context, tx *Tx) error {
+							require.Nil(t, tx.Insert(ctx, &MockModel{IntField: 3}))
+							return someErr
+						})
+						require.Equal(t, someErr, err)
+
+						return nil
+					})
+					require.Nil(t, err)
+
+					err = tx.RunInSavepoint(ctx, func(ctx context.Context, tx *Tx) error {
+						require.Nil(t, tx.Insert(ctx, &MockModel{IntField: 4}))
+
+						// a failed statement aborts the transaction until rolled back to the savepoint
+						_, err := tx.Exec("select * from does_not_exist")
+						return err
+					})
+					require.NotNil(t, err)
+
+					// savepoints rolled back to are released too
+					require.Len(t, tx.marks, 0)
+
+					require.Equal(t, []int{1, 2}, intFields(t, tx))
+					return nil
+				})
+				require.Nil(t, err)
+
+				require.Equal(t, []int{1, 2}, intFields(t, c))
+			},
+		},
+		"panic": {
+			Run: func(t *testing.T, c *Client) {
+				ctx := context.Background()
+
+				tx, err := c.BeginTx(ctx, nil)
+				require.Nil(t, err)
+
+				require.Nil(t, tx.Insert(ctx, &MockModel{IntField: 1}))
+
+				func() {
+					defer func() {
+						require.NotNil(t, recover())
+					}()
+
+					_ = tx.RunInSavepoint(ctx, func(ctx context.Context, tx *Tx) error {
+						require.Nil(t, tx.Insert(ctx, &MockModel{IntField: 2}))
+						panic(errors.New("error for use in panic"))
+					})
+				}()
+
+				require.Equal(t, 0, tx.savepoints)
+				require.Nil(t, tx.Commit())
+
+				require.Equal(t, []int{1}, intFields(t, c))
+			},
+		},
+		"error rolls back the transaction": {
+			Run: func(t *testing.T, c *Client) {
+				ctx := context.Background()
+				someErr := errors.New("some error")
+
+				err := RunInTransaction(ctx, c, func(ctx context.Context, tx *Tx) error {
+					require.Nil(t, tx.Insert(ctx, &MockModel{IntField: 1}))
+					return someErr
+				})
+				require.Equal(t, someErr, err)
+
+				require.Len(t, intFields(t, c), 0)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			c := NewClient(nil)
+
+			if err := c.Start(""); err != nil {
+				t.Fatalf("Failed to start %v", err)
+			}
+
+			if _, err := c.Exec(modelsTable); err != nil {
+				t.Fatalf("failed to create table %v", err)
+			}
+
+			defer func() {
+				_, _ = c.Exec("drop table mock_models")
+				_ = c.Close()
+			}()
+
+			tc.Run(t, c)
+		})
+	}
+}
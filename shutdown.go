@@ -0,0 +1,251 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrClientShutdown is returned for queries, transactions, bulk inserts, advisory locks and subscriptions
+// started after Shutdown was called
+var ErrClientShutdown = errors.New("psql client is shut down")
+
+// ShutdownError is returned by Shutdown when ctx was done before the running work finished, the counts are
+// of the work abandoned when the connections were closed
+type ShutdownError struct {
+	Queries  int
+	Txs      int
+	Locks    int // session level advisory locks
+	Monitors int // MonitorBulkInsertChannel loops
+	Err      error
+}
+
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf("psql client shut down abandoning %d queries, %d transactions, %d advisory locks and %d bulk insert monitors: %v",
+		e.Queries, e.Txs, e.Locks, e.Monitors, e.Err)
+}
+
+func (e *ShutdownError) Unwrap() error {
+	return e.Err
+}
+
+type workKind int
+
+const (
+	workQuery workKind = iota
+	workTx
+	workLock
+	workMonitor
+)
+
+// work tracks what is running on a client and its replicas so Shutdown can wait for it, the methods are
+// no-ops on nil for clients that weren't started
+type work struct {
+	mu      sync.Mutex
+	closing bool
+	running [4]int        // by workKind
+	changed chan struct{} // closed when work finishes while Shutdown waits
+
+	ctx     context.Context // canceled when Shutdown abandons the running work
+	abandon context.CancelFunc
+
+	closers map[io.Closer]struct{} // closed when Shutdown starts, e.g. subscriptions
+}
+
+// add records started work, it fails with ErrClientShutdown when the client is shutting down
+func (w *work) add(k workKind) error {
+	if w == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closing {
+		return ErrClientShutdown
+	}
+	w.running[k]++
+
+	return nil
+}
+
+// hold records a query whose rows outlive the call running it, the returned func releases it once and
+// is called when the rows are closed
+func (w *work) hold() (func(), error) {
+	if err := w.add(workQuery); err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			w.done(workQuery)
+		})
+	}, nil
+}
+
+// holdRows records a query run on c until its rows are released when c is a *Client, the work of a Tx is
+// recorded until it ends
+func holdRows(c QueryClient) (func(), error) {
+	if client, ok := c.(*Client); ok {
+		return client.work.hold()
+	}
+
+	return func() {}, nil
+}
+
+func (w *work) done(k workKind) {
+	if w == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.running[k]--
+	if w.changed != nil {
+		close(w.changed)
+		w.changed = nil
+	}
+}
+
+// context returns a context canceled when Shutdown abandons the running work, for work that must end
+// then rather than when the connections are closed
+func (w *work) context() context.Context {
+	if w == nil {
+		return context.Background()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.initContext()
+	return w.ctx
+}
+
+// initContext creates the context of context, w.mu must be held
+func (w *work) initContext() {
+	if w.ctx == nil {
+		w.ctx, w.abandon = context.WithCancel(context.Background())
+	}
+}
+
+// track records c to be closed when Shutdown starts, it fails with ErrClientShutdown when the client is
+// shutting down
+func (w *work) track(c io.Closer) error {
+	if w == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closing {
+		return ErrClientShutdown
+	}
+
+	if w.closers == nil {
+		w.closers = make(map[io.Closer]struct{})
+	}
+	w.closers[c] = struct{}{}
+
+	return nil
+}
+
+func (w *work) untrack(c io.Closer) {
+	if w == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.closers, c)
+}
+
+func (w *work) isClosing() bool {
+	if w == nil {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.closing
+}
+
+// wait marks the client as closing, closes the tracked closers and waits until nothing is running or ctx
+// is done, the context of the work is canceled then
+func (w *work) wait(ctx context.Context) error {
+	if w == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	w.closing = true
+	closers := w.closers
+	w.closers = nil
+	w.mu.Unlock()
+
+	for c := range closers {
+		_ = c.Close()
+	}
+
+	for {
+		w.mu.Lock()
+		w.closing = true
+		running := w.running
+		if w.changed == nil {
+			w.changed = make(chan struct{})
+		}
+		changed := w.changed
+		w.mu.Unlock()
+
+		if running == [4]int{} {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			w.mu.Lock()
+			running = w.running
+			w.initContext()
+			w.abandon()
+			w.mu.Unlock()
+
+			return &ShutdownError{
+				Queries:  running[workQuery],
+				Txs:      running[workTx],
+				Locks:    running[workLock],
+				Monitors: running[workMonitor],
+				Err:      ctx.Err(),
+			}
+		}
+	}
+}
+
+// Shutdown stops the client gracefully. New queries, transactions, bulk inserts, advisory locks and
+// subscriptions fail with ErrClientShutdown and the subscriptions are closed while running queries, open
+// transactions, held advisory locks and MonitorBulkInsertChannel loops are waited for. Transactions can
+// still run queries and monitors still insert until their channel is closed. The rows of Select queries
+// and RawQuery are waited for until their QueryResult is closed, those of QueryContext only until it
+// returns. The connections are closed once everything finished or ctx is done, a *ShutdownError reports
+// what was abandoned then and bulk inserts and advisory locks still running are ended.
+//
+// A client can't be started again after Shutdown.
+func (c *Client) Shutdown(ctx context.Context) error {
+	err := c.work.wait(ctx)
+
+	if c.metrics != nil {
+		c.metrics.Stop()
+	}
+
+	if closeErr := c.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
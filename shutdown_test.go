@@ -0,0 +1,200 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWork(t *testing.T) {
+	w := &work{}
+	require.Nil(t, w.add(workQuery))
+	require.Nil(t, w.add(workTx))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := w.wait(ctx)
+	require.Equal(t, &ShutdownError{Queries: 1, Txs: 1, Err: context.DeadlineExceeded}, err)
+	require.EqualError(t, err, "psql client shut down abandoning 1 queries, 1 transactions, 0 advisory locks and 0 bulk insert monitors: context deadline exceeded")
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	// the abandoned work is ended
+	require.Equal(t, context.Canceled, w.context().Err())
+
+	// new work is rejected
+	require.Equal(t, ErrClientShutdown, w.add(workQuery))
+	require.Equal(t, ErrClientShutdown, w.track(&closer{}))
+
+	done := make(chan error)
+	go func() {
+		done <- w.wait(context.Background())
+	}()
+
+	w.done(workQuery)
+	w.done(workTx)
+	require.Nil(t, <-done)
+
+	// clients that weren't created with NewClient track nothing
+	var nilWork *work
+	require.Nil(t, nilWork.add(workQuery))
+	nilWork.done(workQuery)
+	require.Nil(t, nilWork.track(&closer{}))
+	require.Nil(t, nilWork.context().Err())
+	require.Nil(t, nilWork.wait(ctx))
+}
+
+type closer struct {
+	closed int
+}
+
+func (c *closer) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestWork_HoldAndTrack(t *testing.T) {
+	w := &work{}
+
+	release, err := w.hold()
+	require.Nil(t, err)
+
+	tracked, untracked := &closer{}, &closer{}
+	require.Nil(t, w.track(tracked))
+	require.Nil(t, w.track(untracked))
+	w.untrack(untracked)
+
+	done := make(chan error)
+	go func() {
+		done <- w.wait(context.Background())
+	}()
+
+	// closers are closed right away while held rows are waited for
+	require.Eventually(t, w.isClosing, time.Second, time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("shut down with held rows: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	release()
+	release()
+	require.Nil(t, <-done)
+
+	require.Equal(t, 1, tracked.closed)
+	require.Equal(t, 0, untracked.closed)
+	require.Equal(t, [4]int{}, w.running)
+}
+
+func TestClient_ShutdownNotStarted(t *testing.T) {
+	c := NewClient(nil)
+	require.Nil(t, c.Stop())
+	require.Nil(t, c.Shutdown(context.Background()))
+	require.Equal(t, ErrClientShutdown, c.Start(""))
+}
+
+func TestClient_ShutdownMonitor(t *testing.T) {
+	c := NewClient(nil)
+	require.Nil(t, c.Start(""))
+
+	ch := make(chan Model)
+	monitorDone := make(chan error)
+	go func() {
+		monitorDone <- c.MonitorBulkInsertChannel(ch, nil)
+	}()
+
+	// wait for the monitor to be running
+	require.Eventually(t, func() bool {
+		c.work.mu.Lock()
+		defer c.work.mu.Unlock()
+		return c.work.running[workMonitor] == 1
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	require.Equal(t, &ShutdownError{Monitors: 1, Err: context.DeadlineExceeded}, c.Shutdown(ctx))
+	require.Equal(t, ErrClientShutdown, c.MonitorBulkInsertChannel(make(chan Model), nil))
+
+	close(ch)
+	require.Nil(t, <-monitorDone)
+}
+
+func TestClient_Shutdown(t *testing.T) {
+	c := NewClient(nil)
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+
+	ctx := context.Background()
+	tx, err := c.BeginTx(ctx, nil)
+	require.Nil(t, err)
+
+	shutdown := make(chan error)
+	go func() {
+		shutdown <- c.Shutdown(ctx)
+	}()
+
+	require.Eventually(t, c.work.isClosing, time.Second, time.Millisecond)
+
+	_, err = c.ExecContext(ctx, "SELECT 1")
+	require.Equal(t, ErrClientShutdown, err)
+
+	_, err = c.BeginTx(ctx, nil)
+	require.Equal(t, ErrClientShutdown, err)
+
+	// the open transaction keeps working until it ends
+	_, err = tx.ExecContext(ctx, "SELECT 1")
+	require.Nil(t, err)
+
+	select {
+	case err := <-shutdown:
+		t.Fatalf("shut down with an open transaction: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	require.Nil(t, tx.Commit())
+	require.Nil(t, <-shutdown)
+
+	// results are waited for until they are closed
+	c = NewClient(nil)
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+
+	r, err := c.RawQuery(ctx, "SELECT generate_series(1, 3)")
+	require.Nil(t, err)
+
+	go func() {
+		shutdown <- c.Shutdown(ctx)
+	}()
+
+	require.Eventually(t, c.work.isClosing, time.Second, time.Millisecond)
+	require.True(t, r.Next())
+
+	select {
+	case err := <-shutdown:
+		t.Fatalf("shut down with open rows: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	require.Nil(t, r.Close())
+	require.Nil(t, <-shutdown)
+
+	// abandoned transactions are reported
+	c = NewClient(nil)
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+
+	_, err = c.BeginTx(ctx, nil)
+	require.Nil(t, err)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	require.Equal(t, &ShutdownError{Txs: 1, Err: context.DeadlineExceeded}, c.Shutdown(timeoutCtx))
+}
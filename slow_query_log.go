@@ -0,0 +1,232 @@
+package psql
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Redacted replaces argument values that should not be logged
+const Redacted = "[REDACTED]"
+
+var (
+	pkgPath = reflect.TypeOf(Client{}).PkgPath()
+
+	fingerprintListRegexp = regexp.MustCompile(`\(\s*\?(\s*,\s*\?)+\s*\)`)
+)
+
+// SlowQuery describes a statement that exceeded the SlowQueryLog threshold
+type SlowQuery struct {
+	Op          Operation
+	Table       string
+	SQL         string
+	Fingerprint string // SQL with literals and placeholders replaced by ?
+	Caller      string // file:line of the first caller outside of go-psql
+	Args        []interface{}
+	Duration    time.Duration
+	Err         error
+}
+
+func (q SlowQuery) String() string {
+	var b StringsBuilder
+	b.WriteStrings("slow query (", q.Duration.String(), ") at ", q.Caller, ": ", q.SQL)
+	b.WriteStrings(" fingerprint=", fmt.Sprintf("%q", q.Fingerprint), " args=", fmt.Sprintf("%v", q.Args))
+	if q.Err != nil {
+		b.WriteStrings(" error=", q.Err.Error())
+	}
+
+	return b.String()
+}
+
+// SlowQueryLog is a Hook that logs statements taking longer than Threshold, see WithSlowQueryLog.
+//
+// Rows returning statements are timed until the rows are returned, not until they have been read.
+type SlowQueryLog struct {
+	Threshold time.Duration
+
+	// DenyColumns are columns (or named parameters) whose argument values are redacted,
+	// EncryptableString values are always redacted
+	DenyColumns []string
+
+	// Func receives each slow query, by default they are printed with the standard logger
+	Func func(SlowQuery)
+}
+
+// WithSlowQueryLog logs statements run through the client and its transactions that exceed l.Threshold
+func WithSlowQueryLog(l SlowQueryLog) ClientOption {
+	return WithHooks(l)
+}
+
+func (l SlowQueryLog) BeforeQuery(ctx context.Context, e *QueryEvent) context.Context {
+	return ctx
+}
+
+func (l SlowQueryLog) AfterQuery(ctx context.Context, e *QueryEvent) {
+	if l.Threshold <= 0 || e.Duration < l.Threshold {
+		return
+	}
+
+	q := SlowQuery{
+		Op:          e.Op,
+		Table:       e.Table,
+		SQL:         e.SQL,
+		Fingerprint: Fingerprint(e.SQL),
+		Caller:      callerLocation(),
+		Args:        redactArgs(e.Args, e.ArgColumns, l.DenyColumns),
+		Duration:    e.Duration,
+		Err:         e.Err,
+	}
+
+	if l.Func != nil {
+		l.Func(q)
+		return
+	}
+
+	log.Printf("psql: %v", q)
+}
+
+// Fingerprint normalizes a statement so that similar statements can be grouped. String, number and
+// dollar quoted literals and $n placeholders are replaced by ?, lists of them are collapsed into (?),
+// comments are removed and whitespace is collapsed.
+func Fingerprint(q string) string {
+	var b strings.Builder
+
+	n := len(q)
+	space := false
+	for i := 0; i < n; {
+		c := q[i]
+
+		var next int
+		var out string
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			i++
+			continue
+		case c == '\'':
+			escapes := i > 0 && (q[i-1] == 'E' || q[i-1] == 'e') && (i < 2 || !isIdentChar(q[i-2]))
+			if escapes && b.Len() > 0 {
+				// drop the E prefix that was already written
+				s := b.String()
+				b.Reset()
+				b.WriteString(s[:len(s)-1])
+			}
+			next, out = skipQuoted(q, i, '\'', escapes), "?"
+		case c == '"':
+			next = skipQuoted(q, i, '"', false)
+			out = q[i:next]
+		case c == '-' && i+1 < n && q[i+1] == '-':
+			next = strings.IndexByte(q[i:], '\n')
+			if next == -1 {
+				next = n
+			} else {
+				next += i
+			}
+			space = true
+			i = next
+			continue
+		case c == '/' && i+1 < n && q[i+1] == '*':
+			space = true
+			i = skipBlockComment(q, i)
+			continue
+		case c == '$' && (i == 0 || !isIdentChar(q[i-1])):
+			next = i + 1
+			for next < n && q[next] >= '0' && q[next] <= '9' {
+				next++
+			}
+			if next == i+1 {
+				next = skipDollarQuoted(q, i)
+			}
+
+			out = "?"
+			if next == i+1 {
+				// a lone $
+				out = "$"
+			}
+		case c >= '0' && c <= '9' && (i == 0 || !isIdentChar(q[i-1])):
+			next = i + 1
+			for next < n && (isIdentChar(q[next]) || q[next] == '.') {
+				next++
+			}
+			out = "?"
+		default:
+			next, out = i+1, q[i:i+1]
+		}
+
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+
+		b.WriteString(out)
+		i = next
+	}
+
+	return fingerprintListRegexp.ReplaceAllString(b.String(), "(?)")
+}
+
+// redactArgs returns a copy of args with EncryptableString values and values bound to deny listed columns redacted
+func redactArgs(args []interface{}, cols []string, deny []string) []interface{} {
+	if len(args) == 0 {
+		return args
+	}
+
+	redacted := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch arg.(type) {
+		case EncryptableString, *EncryptableString:
+			redacted[i] = Redacted
+			continue
+		}
+
+		if i < len(cols) && cols[i] != "" && denied(cols[i], deny) {
+			redacted[i] = Redacted
+			continue
+		}
+
+		redacted[i] = arg
+	}
+
+	return redacted
+}
+
+func denied(col string, deny []string) bool {
+	for _, d := range deny {
+		if strings.EqualFold(col, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// callerLocation returns the file:line of the first caller outside of go-psql and database/sql
+func callerLocation() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		f, more := frames.Next()
+		if !internalFrame(f) {
+			return fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+func internalFrame(f runtime.Frame) bool {
+	if strings.HasPrefix(f.Function, "database/sql.") {
+		return true
+	}
+
+	return strings.HasPrefix(f.Function, pkgPath+".") && !strings.HasSuffix(f.File, "_test.go")
+}
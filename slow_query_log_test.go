@@ -0,0 +1,127 @@
+package psql
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	tcs := map[string]struct {
+		SQL      string
+		Expected string
+	}{
+		"placeholders and lists": {
+			SQL:      `SELECT * FROM "mock_models" WHERE "id" IN ($1, $2, $3) AND "int_field" = $4`,
+			Expected: `SELECT * FROM "mock_models" WHERE "id" IN (?) AND "int_field" = ?`,
+		},
+		"literals": {
+			SQL:      "select a1 from t where b = 'it''s' and c = E'\\'x' and d > 10.5 and e = $$x$$",
+			Expected: "select a1 from t where b = ? and c = ? and d > ? and e = ?",
+		},
+		"comments and whitespace": {
+			SQL:      "select  *\n\tfrom t -- comment\n where /* block */ x = 1",
+			Expected: "select * from t where x = ?",
+		},
+		"quoted identifiers kept": {
+			SQL:      `select "col 1" from "t$1"`,
+			Expected: `select "col 1" from "t$1"`,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.Expected, Fingerprint(tc.SQL))
+		})
+	}
+}
+
+func TestRedactArgs(t *testing.T) {
+	es := NewEncryptableString("secret")
+	args := []interface{}{"a", es, &es, "b", 5}
+	cols := []string{"name", "", "", "Password"}
+
+	redacted := redactArgs(args, cols, []string{"password"})
+	require.Equal(t, []interface{}{"a", Redacted, Redacted, Redacted, 5}, redacted)
+
+	// the original args are untouched
+	require.Equal(t, "b", args[3])
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	var logged []SlowQuery
+	l := SlowQueryLog{
+		Threshold:   10 * time.Millisecond,
+		DenyColumns: []string{"string_field"},
+		Func: func(q SlowQuery) {
+			logged = append(logged, q)
+		},
+	}
+
+	e := &QueryEvent{
+		Op:         OpSelect,
+		Table:      "mock_models",
+		SQL:        `SELECT * FROM "mock_models" WHERE "string_field" = $1 AND "int_field" = $2`,
+		Args:       []interface{}{"secret", 2},
+		ArgColumns: []string{"string_field", "int_field"},
+		Duration:   5 * time.Millisecond,
+	}
+
+	l.AfterQuery(context.Background(), e)
+	require.Len(t, logged, 0)
+
+	e.Duration = 20 * time.Millisecond
+	l.AfterQuery(context.Background(), e)
+	require.Len(t, logged, 1)
+
+	q := logged[0]
+	require.Equal(t, []interface{}{Redacted, 2}, q.Args)
+	require.Equal(t, `SELECT * FROM "mock_models" WHERE "string_field" = ? AND "int_field" = ?`, q.Fingerprint)
+	require.True(t, strings.Contains(q.Caller, "slow_query_log_test.go:"), q.Caller)
+	require.False(t, strings.Contains(q.String(), "secret"))
+}
+
+func TestClient_SlowQueryLog(t *testing.T) {
+	var logged []SlowQuery
+	c := NewClient(nil, WithSlowQueryLog(SlowQueryLog{
+		Threshold:   20 * time.Millisecond,
+		DenyColumns: []string{"string_field"},
+		Func: func(q SlowQuery) {
+			logged = append(logged, q)
+		},
+	}))
+
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+
+	if _, err := c.Exec(modelsTable); err != nil {
+		t.Fatalf("failed to create table %v", err)
+	}
+
+	defer func() {
+		_, _ = c.Exec("drop table mock_models")
+		_ = c.Close()
+	}()
+
+	ctx := context.Background()
+
+	// the sleeping where clause needs a row to run on
+	_, err := c.Exec("insert into mock_models (string_field) values ('secret')")
+	require.Nil(t, err)
+
+	var results []*MockModel
+	err = c.Select("mock_models").Where(Attrs{"string_field": "secret"}).Slice(ctx, &results)
+	require.Nil(t, err)
+	require.Len(t, logged, 0)
+
+	err = c.Select("mock_models").Where(Attrs{"string_field": "secret"}).WhereRaw("pg_sleep(%v) IS NOT NULL", 0.05).Slice(ctx, &results)
+	require.Nil(t, err)
+	require.Len(t, logged, 1)
+	require.Equal(t, []interface{}{Redacted, 0.05}, logged[0].Args)
+	require.Equal(t, "mock_models", logged[0].Table)
+	require.True(t, strings.Contains(logged[0].Caller, "slow_query_log_test.go:"), logged[0].Caller)
+}
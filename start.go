@@ -0,0 +1,231 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Errors of the server checks of StartContext, use with errors.Is on the returned *StartError
+var (
+	ErrServerVersion    = errors.New("server version too old")
+	ErrMissingExtension = errors.New("missing required extension")
+)
+
+// DefaultStartBackoff is used for the zero delays of StartOptions.Backoff
+var DefaultStartBackoff = RetryPolicy{
+	BaseDelay: 100 * time.Millisecond,
+	MaxDelay:  5 * time.Second,
+}
+
+// StartOptions configures how StartContext starts a client
+type StartOptions struct {
+	DriverName string // "postgres" when empty
+
+	// WaitForServer pings the server until it accepts connections, e.g. while its container starts. Without
+	// it the server is only connected to by the first query unless it has to be checked below.
+	WaitForServer bool
+
+	// Backoff sets the delays between pings, a zero MaxAttempts pings until ctx is done
+	Backoff RetryPolicy
+
+	// MinServerVersion is compared with server_version_num, e.g. 100000 for 10.0
+	MinServerVersion int
+
+	// RequiredExtensions must be installed in the database, e.g. "pgcrypto"
+	RequiredExtensions []string
+}
+
+func (o StartOptions) checksServer() bool {
+	return o.WaitForServer || o.MinServerVersion > 0 || len(o.RequiredExtensions) > 0
+}
+
+// StartError describes a failed StartContext
+type StartError struct {
+	Target   string        // the connection string with the password redacted
+	Step     string        // "ping", "server version" or "extensions"
+	Attempts int           // pings made
+	Elapsed  time.Duration // since StartContext was called
+
+	ServerVersion     int      // server_version_num when it was read
+	MissingExtensions []string // required extensions not installed
+
+	Err error // the last error
+}
+
+func (e *StartError) Error() string {
+	return fmt.Sprintf("unable to start psql client: %v failed after %d attempt(s) in %v (%v): %v",
+		e.Step, e.Attempts, e.Elapsed.Round(time.Millisecond), e.Target, e.Err)
+}
+
+func (e *StartError) Unwrap() error {
+	return e.Err
+}
+
+// StartContext opens the connection pool like Start and checks the server as configured by o, see
+// StartOptions. Failed checks return a *StartError, the client is only started when all pass.
+func (c *Client) StartContext(ctx context.Context, o StartOptions) error {
+	start := time.Now()
+
+	if o.DriverName == "" {
+		o.DriverName = "postgres"
+	}
+
+	if c.configErr != nil {
+		return c.configErr
+	}
+
+	if c.work == nil {
+		c.work = &work{}
+	} else if c.work.isClosing() {
+		return ErrClientShutdown
+	}
+
+	db, err := sql.Open(o.DriverName, c.connStr)
+	if err != nil {
+		return fmt.Errorf("unable to open connection to postgres db: %w", err)
+	}
+
+	c.pool.apply(db)
+
+	if o.checksServer() {
+		if err := c.checkServer(ctx, db, o, start); err != nil {
+			_ = db.Close()
+			return err
+		}
+	}
+
+	if err := c.pool.warm(ctx, db); err != nil {
+		_ = db.Close()
+		return err
+	}
+
+	if c.replicas != nil {
+		if err := c.replicas.open(o.DriverName, c); err != nil {
+			_ = db.Close()
+			return err
+		}
+	}
+
+	c.DB = db
+
+	if c.metrics != nil {
+		c.metrics.Start(db)
+	}
+
+	return nil
+}
+
+func (c *Client) checkServer(ctx context.Context, db *sql.DB, o StartOptions, start time.Time) error {
+	e := &StartError{Target: c.target, Step: "ping"}
+	fail := func(err error) error {
+		e.Err, e.Elapsed = err, time.Since(start)
+		return e
+	}
+
+	if err := ping(ctx, db, o, &e.Attempts); err != nil {
+		return fail(err)
+	}
+
+	if o.MinServerVersion > 0 {
+		e.Step = "server version"
+
+		if err := db.QueryRowContext(ctx, "SELECT current_setting('server_version_num')::int").Scan(&e.ServerVersion); err != nil {
+			return fail(err)
+		}
+
+		if e.ServerVersion < o.MinServerVersion {
+			return fail(fmt.Errorf("%w: %d is older than %d", ErrServerVersion, e.ServerVersion, o.MinServerVersion))
+		}
+	}
+
+	if len(o.RequiredExtensions) > 0 {
+		e.Step = "extensions"
+
+		missing, err := missingExtensions(ctx, db, o.RequiredExtensions)
+		if err != nil {
+			return fail(err)
+		}
+
+		if len(missing) > 0 {
+			e.MissingExtensions = missing
+			return fail(fmt.Errorf("%w: %v", ErrMissingExtension, strings.Join(missing, ", ")))
+		}
+	}
+
+	return nil
+}
+
+// ping pings db until it succeeds, only once unless o.WaitForServer
+func ping(ctx context.Context, db *sql.DB, o StartOptions, attempts *int) error {
+	p := o.Backoff
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultStartBackoff.BaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultStartBackoff.MaxDelay
+	}
+	if p.MaxDelay < p.BaseDelay {
+		p.MaxDelay = p.BaseDelay
+	}
+
+	for {
+		*attempts++
+
+		err := db.PingContext(ctx)
+		if err == nil || !o.WaitForServer || (p.MaxAttempts > 0 && *attempts >= p.MaxAttempts) {
+			return err
+		}
+
+		delay := p.backoff(*attempts, jitter)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return err
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+// missingExtensions returns the sorted extensions not installed in the database
+func missingExtensions(ctx context.Context, db *sql.DB, required []string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT extname FROM pg_extension WHERE extname = ANY($1)", pq.Array(required))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	installed := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		installed[name] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, name := range required {
+		if !installed[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+
+	return missing, nil
+}
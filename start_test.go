@@ -0,0 +1,96 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartError(t *testing.T) {
+	err := &StartError{
+		Target:   "host='localhost' options=''",
+		Step:     "ping",
+		Attempts: 3,
+		Elapsed:  1234567 * time.Microsecond,
+		Err:      errors.New("connection refused"),
+	}
+
+	require.EqualError(t, err, "unable to start psql client: ping failed after 3 attempt(s) in 1.235s (host='localhost' options=''): connection refused")
+	require.True(t, errors.Is(err, err.Err))
+}
+
+func TestClient_StartContextUnreachable(t *testing.T) {
+	unreachable := &Config{Host: "127.0.0.1", Port: "1", Password: "secret", SSLMode: "disable", ConnectTimeout: "1"}
+
+	// without checks the server isn't connected to
+	c := NewClient(unreachable)
+	require.Nil(t, c.StartContext(context.Background(), StartOptions{}))
+	require.True(t, c.Started())
+	require.Nil(t, c.Stop())
+
+	c = NewClient(unreachable)
+	err := c.StartContext(context.Background(), StartOptions{
+		WaitForServer: true,
+		Backoff:       RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+	})
+
+	var startErr *StartError
+	require.True(t, errors.As(err, &startErr), err)
+	require.Equal(t, "ping", startErr.Step)
+	require.Equal(t, 3, startErr.Attempts)
+	require.NotNil(t, startErr.Err)
+	require.Contains(t, startErr.Target, "port='1'")
+	require.NotContains(t, err.Error(), "secret")
+	require.False(t, c.Started())
+
+	// pings until the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	err = NewClient(unreachable).StartContext(ctx, StartOptions{
+		WaitForServer: true,
+		Backoff:       RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond},
+	})
+	require.True(t, errors.As(err, &startErr), err)
+	require.Greater(t, startErr.Attempts, 1)
+	require.Less(t, startErr.Elapsed, time.Second)
+
+	// the server checks connect even without waiting
+	err = NewClient(unreachable).StartContext(context.Background(), StartOptions{MinServerVersion: 100000})
+	require.True(t, errors.As(err, &startErr), err)
+	require.Equal(t, 1, startErr.Attempts)
+}
+
+func TestClient_StartContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c := NewClient(nil)
+	err := c.StartContext(ctx, StartOptions{
+		WaitForServer:      true,
+		MinServerVersion:   90600,
+		RequiredExtensions: []string{"plpgsql"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+	defer c.Close()
+
+	require.True(t, c.Started())
+
+	var startErr *StartError
+
+	err = NewClient(nil).StartContext(context.Background(), StartOptions{MinServerVersion: 990000})
+	require.True(t, errors.Is(err, ErrServerVersion), err)
+	require.True(t, errors.As(err, &startErr))
+	require.Equal(t, "server version", startErr.Step)
+	require.Greater(t, startErr.ServerVersion, 90600)
+
+	err = NewClient(nil).StartContext(context.Background(), StartOptions{RequiredExtensions: []string{"plpgsql", "not_installed", "also_missing"}})
+	require.True(t, errors.Is(err, ErrMissingExtension), err)
+	require.True(t, errors.As(err, &startErr))
+	require.Equal(t, []string{"also_missing", "not_installed"}, startErr.MissingExtensions)
+}
@@ -0,0 +1,124 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrStatementTimeout matches errors caused by postgres canceling a statement due to statement_timeout
+//
+//	errors.Is(err, ErrStatementTimeout)
+var ErrStatementTimeout = errors.New("statement timeout")
+
+// TimeoutError is returned when postgres cancels a statement because it exceeded statement_timeout.
+// Timeout is 0 when the timeout was not set by go-psql (e.g. set on the role or database).
+type TimeoutError struct {
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Timeout == 0 {
+		return fmt.Sprintf("statement timeout: %v", e.Err)
+	}
+
+	return fmt.Sprintf("statement timeout after %v: %v", e.Timeout, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrStatementTimeout
+}
+
+// statementTimeouter is implemented by clients that have a default statement timeout
+type statementTimeouter interface {
+	StatementTimeout() time.Duration
+}
+
+// withStatementTimeout returns the client to run a statement on with statement_timeout applied, 0
+// disables the timeout. The returned func must be called with the statement's error once it is done, it
+// commits or rolls back the transaction started for a Client or restores the previous timeout of a Tx.
+// It is nil if there is nothing to apply.
+func withStatementTimeout(ctx context.Context, c QueryClient, d time.Duration) (QueryClient, func(error) error, error) {
+	if d < 0 {
+		return c, nil, nil
+	}
+
+	switch c := c.(type) {
+	case *Tx:
+		var prev, cur string
+		q := "SELECT current_setting('statement_timeout'), set_config('statement_timeout', $1, true)"
+		if err := c.Tx.QueryRowContext(ctx, q, timeoutSetting(d)).Scan(&prev, &cur); err != nil {
+			return nil, nil, err
+		}
+
+		return c, func(err error) error {
+			_, restoreErr := c.Tx.ExecContext(context.Background(), "SELECT set_config('statement_timeout', $1, true)", prev)
+			if err != nil {
+				// restoring fails when the error aborted the transaction
+				return err
+			}
+
+			return restoreErr
+		}, nil
+	case *Client:
+		tx, err := c.beginTx(ctx, txOptions{timeout: d, timeoutSet: true})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return tx, func(err error) error {
+			if err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+
+			return tx.Commit()
+		}, nil
+	default:
+		return c, nil, nil
+	}
+}
+
+// timeoutError wraps errors caused by statement_timeout in a TimeoutError
+func timeoutError(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "57014" || !strings.Contains(pqErr.Message, "statement timeout") {
+		return err
+	}
+
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return err
+	}
+
+	return &TimeoutError{Timeout: d, Err: err}
+}
+
+// timeoutSetting formats a duration for statement_timeout which is in milliseconds, 0 disables it
+func timeoutSetting(d time.Duration) string {
+	if d <= 0 {
+		return "0"
+	}
+
+	ms := d.Milliseconds()
+	if ms < 1 {
+		// 0 disables the timeout so round up
+		ms = 1
+	}
+
+	return strconv.FormatInt(ms, 10)
+}
@@ -0,0 +1,168 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutError(t *testing.T) {
+	timeoutErr := &pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"}
+	canceledErr := &pq.Error{Code: "57014", Message: "canceling statement due to user request"}
+
+	err := timeoutError(fmt.Errorf("wrapped %w", timeoutErr), time.Second)
+	require.True(t, errors.Is(err, ErrStatementTimeout))
+
+	var te *TimeoutError
+	require.True(t, errors.As(err, &te))
+	require.Equal(t, time.Second, te.Timeout)
+
+	var pqErr *pq.Error
+	require.True(t, errors.As(err, &pqErr))
+
+	// not wrapped twice
+	require.Equal(t, err, timeoutError(err, time.Second))
+
+	require.False(t, errors.Is(timeoutError(canceledErr, time.Second), ErrStatementTimeout))
+	require.Nil(t, timeoutError(nil, time.Second))
+}
+
+func TestTimeoutSetting(t *testing.T) {
+	require.Equal(t, "1500", timeoutSetting(1500*time.Millisecond))
+	require.Equal(t, "1", timeoutSetting(time.Microsecond))
+	require.Equal(t, "0", timeoutSetting(0))
+}
+
+func TestClient_StatementTimeoutOption(t *testing.T) {
+	// the default is set once per connection
+	c := NewClient(&Config{DbName: "flights"}, WithStatementTimeout(time.Second))
+	require.Equal(t, time.Second, c.StatementTimeout())
+	require.Equal(t, `dbname='flights' options='-c statement_timeout=1000'`, c.connStr)
+
+	c = NewClient(&Config{DbName: "flights", StatementTimeout: 2 * time.Second})
+	require.Equal(t, 2*time.Second, c.StatementTimeout())
+	require.Equal(t, `dbname='flights' options='-c statement_timeout=2000'`, c.connStr)
+
+	dsn, err := withDSNStatementTimeout("host=replica", time.Second)
+	require.Nil(t, err)
+	require.Equal(t, `host='replica' options='-c statement_timeout=1000'`, dsn)
+
+	dsn, err = withDSNStatementTimeout("postgres://replica/flights?statement_timeout=5s", time.Second)
+	require.Nil(t, err)
+	require.Equal(t, `dbname='flights' host='replica' options='-c statement_timeout=5s'`, dsn)
+
+	dsn, err = withDSNStatementTimeout("host=replica", 0)
+	require.Nil(t, err)
+	require.Equal(t, "host=replica", dsn)
+}
+
+func TestQuery_Timeout(t *testing.T) {
+	tcs := map[string]struct {
+		Opts []ClientOption
+		Run  func(*testing.T, *Client)
+	}{
+		"query timeout": {
+			Run: func(t *testing.T, c *Client) {
+				ctx := context.Background()
+
+				var results []*MockModel
+				err := c.Select("mock_models").WhereRaw("pg_sleep(1) IS NOT NULL").Timeout(10*time.Millisecond).Slice(ctx, &results)
+				require.True(t, errors.Is(err, ErrStatementTimeout))
+
+				err = c.Select("mock_models").Timeout(time.Second).Slice(ctx, &results)
+				require.Nil(t, err)
+			},
+		},
+		"client default": {
+			Opts: []ClientOption{WithStatementTimeout(10 * time.Millisecond)},
+			Run: func(t *testing.T, c *Client) {
+				ctx := context.Background()
+
+				_, err := c.RawQuery(ctx, "SELECT pg_sleep(1)")
+				require.True(t, errors.Is(err, ErrStatementTimeout))
+
+				var results []*MockModel
+				err = c.Select("mock_models").WhereRaw("pg_sleep(1) IS NOT NULL").Slice(ctx, &results)
+				require.True(t, errors.Is(err, ErrStatementTimeout))
+
+				// disabled for a single query
+				err = c.Select("mock_models").WhereRaw("pg_sleep(0.05) IS NOT NULL").Timeout(0).Slice(ctx, &results)
+				require.Nil(t, err)
+
+				m := &MockModel{IntField: 1}
+				require.Nil(t, c.Insert(ctx, m))
+				require.NotZero(t, m.ID)
+			},
+		},
+		"in transaction": {
+			Run: func(t *testing.T, c *Client) {
+				ctx := context.Background()
+
+				tx, err := c.BeginTx(ctx, nil)
+				require.Nil(t, err)
+				defer tx.Rollback()
+
+				var results []*MockModel
+				err = tx.Select("mock_models").Timeout(time.Second).Slice(ctx, &results)
+				require.Nil(t, err)
+
+				// the previous timeout is restored for the rest of the transaction
+				var setting string
+				err = tx.QueryRowContext(ctx, "SHOW statement_timeout").Scan(&setting)
+				require.Nil(t, err)
+				require.Equal(t, "0", setting)
+
+				err = tx.Select("mock_models").WhereRaw("pg_sleep(1) IS NOT NULL").Timeout(10*time.Millisecond).Slice(ctx, &results)
+				require.True(t, errors.Is(err, ErrStatementTimeout))
+			},
+		},
+		"commit error": {
+			Run: func(t *testing.T, c *Client) {
+				ctx := context.Background()
+
+				_, err := c.Exec("CREATE TABLE deferred_models (id int UNIQUE DEFERRABLE INITIALLY DEFERRED)")
+				require.Nil(t, err)
+				defer func() {
+					_, _ = c.Exec("DROP TABLE deferred_models")
+				}()
+
+				var id int
+				err = InsertQuery(c, "deferred_models", Attrs{"id": 1}).Timeout(time.Second).Scan(ctx, &id)
+				require.Nil(t, err)
+
+				// the duplicate is only detected by the commit of the timeout transaction
+				err = InsertQuery(c, "deferred_models", Attrs{"id": 1}).Timeout(time.Second).Scan(ctx, &id)
+				require.True(t, errors.Is(err, ErrUniqueViolation), err)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			c := NewClient(nil, tc.Opts...)
+
+			if err := c.Start(""); err != nil {
+				t.Fatalf("Failed to start %v", err)
+			}
+
+			if _, err := c.Exec(modelsTable); err != nil {
+				t.Fatalf("failed to create table %v", err)
+			}
+
+			defer func() {
+				_, _ = c.Exec("drop table mock_models")
+				_ = c.Close()
+			}()
+
+			// the sleeping where clauses need a row to run on
+			require.Nil(t, c.Insert(context.Background(), &MockModel{}))
+
+			tc.Run(t, c)
+		})
+	}
+}
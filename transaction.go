@@ -3,16 +3,262 @@ package psql
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
 )
 
+// ErrTxEndedInCallback is returned by RunInTransaction when the callback committed or rolled back the
+// transaction itself
+var ErrTxEndedInCallback = errors.New("transaction was committed or rolled back inside the RunInTransaction callback")
+
 type Tx struct {
 	*sql.Tx
+	client *Client
+
+	// mu guards the fields below, some are also set when database/sql rolls back the transaction because
+	// its context is done, see watch
+	mu         sync.Mutex
+	savepoints int       // depth of RunInSavepoint calls
+	outcome    TxOutcome // set once committed or rolled back
+	ctxDone    bool      // rolled back by database/sql
+	ended      chan struct{}
+	callbacks  []txCallback
+	marks      []savepointMark // established savepoints, innermost last
+
+	isolation  sql.IsolationLevel
+	readOnly   bool
+	deferrable bool
+}
+
+type txCallback struct {
+	onCommit bool
+	f        func()
+}
+
+// RollbackError is returned when rolling back after an error failed too, it unwraps to Err
+type RollbackError struct {
+	Err         error
+	RollbackErr error
+}
+
+func (e *RollbackError) Error() string {
+	return fmt.Sprintf("%v (rollback failed: %v)", e.Err, e.RollbackErr)
+}
+
+func (e *RollbackError) Unwrap() error {
+	return e.Err
 }
 
 func (tx *Tx) Started() bool {
 	return tx.Tx != nil
 }
 
+// StatementTimeout returns the client's default which applies to the transaction's connection
+func (tx *Tx) StatementTimeout() time.Duration {
+	if tx.client == nil {
+		return 0
+	}
+
+	return tx.client.statementTimeout
+}
+
+// Commit commits the transaction, serialization failures are returned as an *Error
+func (tx *Tx) Commit() error {
+	tx.mu.Lock()
+	err := tx.Tx.Commit()
+	// postgres rolls back transactions that fail to commit
+	outcome := TxCommitted
+	if err != nil {
+		outcome = TxRolledBack
+	}
+	callbacks := tx.setOutcome(outcome)
+	tx.mu.Unlock()
+
+	runCallbacks(callbacks, outcome)
+
+	return wrapError(err)
+}
+
+func (tx *Tx) Rollback() error {
+	tx.mu.Lock()
+	err := tx.Tx.Rollback()
+	callbacks := tx.setOutcome(TxRolledBack)
+	tx.mu.Unlock()
+
+	runCallbacks(callbacks, TxRolledBack)
+
+	return err
+}
+
+// OnCommit registers f to run once the transaction has committed. Callbacks run in registration order
+// after Commit returns, callbacks registered in a savepoint are discarded if it is rolled back to.
+func (tx *Tx) OnCommit(f func()) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	tx.callbacks = append(tx.callbacks, txCallback{onCommit: true, f: f})
+}
+
+// OnRollback registers f to run once the transaction has rolled back, including when committing it
+// failed or database/sql rolled it back because its context is done, f then runs on another goroutine.
+// Callbacks run in registration order, callbacks registered in a savepoint are discarded if it is rolled
+// back to.
+func (tx *Tx) OnRollback(f func()) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	tx.callbacks = append(tx.callbacks, txCallback{onCommit: false, f: f})
+}
+
+// end records the outcome and runs the callbacks registered for it
+func (tx *Tx) end(outcome TxOutcome) {
+	tx.mu.Lock()
+	callbacks := tx.setOutcome(outcome)
+	tx.mu.Unlock()
+
+	runCallbacks(callbacks, outcome)
+}
+
+// setOutcome records the outcome unless the transaction already ended and returns the callbacks to run,
+// tx.mu must be held
+func (tx *Tx) setOutcome(outcome TxOutcome) []txCallback {
+	if tx.outcome != "" {
+		return nil
+	}
+
+	tx.outcome = outcome
+	if tx.client != nil {
+		tx.client.work.done(workTx)
+	}
+	if tx.ended != nil {
+		close(tx.ended)
+	}
+
+	callbacks := tx.callbacks
+	tx.callbacks, tx.marks = nil, nil
+
+	return callbacks
+}
+
+func runCallbacks(callbacks []txCallback, outcome TxOutcome) {
+	for _, cb := range callbacks {
+		if cb.onCommit == (outcome == TxCommitted) {
+			cb.f()
+		}
+	}
+}
+
+// watch ends the transaction once ctx is done since database/sql rolls it back then, unless it ended before
+func (tx *Tx) watch(ctx context.Context) {
+	done := ctx.Done()
+	if done == nil {
+		return
+	}
+
+	tx.ended = make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+		case <-tx.ended:
+			return
+		}
+
+		tx.mu.Lock()
+		if tx.outcome == "" {
+			tx.ctxDone = true
+		}
+		callbacks := tx.setOutcome(TxRolledBack)
+		tx.mu.Unlock()
+
+		runCallbacks(callbacks, TxRolledBack)
+	}()
+}
+
+// state returns the outcome of the transaction and whether database/sql rolled it back because its
+// context is done
+func (tx *Tx) state() (TxOutcome, bool) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	return tx.outcome, tx.ctxDone
+}
+
+// setStatementTimeout applies statement_timeout until the end of the transaction
+func (tx *Tx) setStatementTimeout(ctx context.Context, d time.Duration) error {
+	_, err := tx.Tx.ExecContext(ctx, "SELECT set_config('statement_timeout', $1, true)", timeoutSetting(d))
+	return err
+}
+
+// QueryContext calls the client's hooks around the transaction's QueryContext
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := tx.client.observe(ctx, newQueryEvent(ctx, query, args), func(ctx context.Context) error {
+		var err error
+		rows, err = tx.Tx.QueryContext(ctx, query, args...)
+		return err
+	})
+
+	return rows, err
+}
+
+// ExecContext calls the client's hooks around the transaction's ExecContext
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	e := newQueryEvent(ctx, query, args)
+	err := tx.client.observe(ctx, e, func(ctx context.Context) error {
+		var err error
+		result, err = tx.Tx.ExecContext(ctx, query, args...)
+		if err == nil {
+			e.RowsAffected, _ = result.RowsAffected()
+		}
+		return err
+	})
+
+	return result, err
+}
+
+// QueryRowContext calls the client's hooks around the transaction's QueryRowContext
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	var row *sql.Row
+	_ = tx.client.observe(ctx, newQueryEvent(ctx, query, args), func(ctx context.Context) error {
+		row = tx.Tx.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
+
+	return row
+}
+
+// PrepareContext calls the client's hooks around the transaction's PrepareContext, executions of the
+// statement don't call the hooks
+func (tx *Tx) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	var stmt *sql.Stmt
+	err := tx.client.observe(ctx, newQueryEvent(ctx, query, nil), func(ctx context.Context) error {
+		var err error
+		stmt, err = tx.Tx.PrepareContext(ctx, query)
+		return err
+	})
+
+	return stmt, err
+}
+
+func (tx *Tx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.QueryContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.ExecContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return tx.QueryRowContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) Prepare(query string) (*sql.Stmt, error) {
+	return tx.PrepareContext(context.Background(), query)
+}
+
 func (tx *Tx) Select(tableName string, cols ...string) *Query {
 	return Select(tx, tableName, cols...)
 }
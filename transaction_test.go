@@ -2,6 +2,7 @@ package psql
 
 import (
 	"context"
+	"errors"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -992,3 +993,175 @@ func TestTx_DeleteReturning(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_RunInTransactionOutcome(t *testing.T) {
+	r := &txRecorder{}
+	c := NewClient(nil, WithHooks(r))
+
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+
+	if _, err := c.Exec(modelsTable); err != nil {
+		t.Fatalf("failed to create table %v", err)
+	}
+
+	defer func() {
+		_, _ = c.Exec("drop table mock_models")
+		_ = c.Close()
+	}()
+
+	ctx := context.Background()
+	someErr := errors.New("some error")
+
+	require.Nil(t, c.RunInTransaction(ctx, func(ctx context.Context, tx *Tx) error { return nil }, nil))
+	require.Equal(t, someErr, c.RunInTransaction(ctx, func(ctx context.Context, tx *Tx) error { return someErr }, nil))
+
+	err := c.RunInTransaction(ctx, func(ctx context.Context, tx *Tx) error {
+		require.Nil(t, tx.Rollback())
+		return nil
+	}, nil)
+	require.Equal(t, ErrTxEndedInCallback, err)
+
+	func() {
+		defer func() {
+			require.Equal(t, someErr, recover())
+		}()
+
+		_ = c.RunInTransaction(ctx, func(ctx context.Context, tx *Tx) error { panic(someErr) }, nil)
+	}()
+
+	require.Len(t, r.events, 4)
+
+	outcomes := make([]TxOutcome, len(r.events))
+	for i, e := range r.events {
+		outcomes[i] = e.Outcome
+	}
+	require.Equal(t, []TxOutcome{TxCommitted, TxRolledBack, TxRolledBack, TxPanicked}, outcomes)
+	require.Equal(t, someErr, r.events[1].Err)
+}
+
+func TestRollbackError(t *testing.T) {
+	someErr := errors.New("some error")
+	err := &RollbackError{Err: someErr, RollbackErr: errors.New("connection lost")}
+
+	require.True(t, errors.Is(err, someErr))
+	require.Equal(t, "some error (rollback failed: connection lost)", err.Error())
+}
+
+func TestTx_Callbacks(t *testing.T) {
+	var calls []string
+	add := func(tx *Tx, onCommit bool, name string) {
+		f := func() { calls = append(calls, name) }
+		if onCommit {
+			tx.OnCommit(f)
+		} else {
+			tx.OnRollback(f)
+		}
+	}
+
+	tx := &Tx{}
+	add(tx, true, "commit 1")
+	add(tx, false, "rollback 1")
+	add(tx, true, "commit 2")
+
+	tx.end(TxCommitted)
+	require.Equal(t, []string{"commit 1", "commit 2"}, calls)
+	require.Len(t, tx.callbacks, 0)
+
+	calls = nil
+	tx = &Tx{}
+	add(tx, true, "commit 1")
+	add(tx, false, "rollback 1")
+	add(tx, false, "rollback 2")
+
+	tx.end(TxRolledBack)
+	require.Equal(t, []string{"rollback 1", "rollback 2"}, calls)
+}
+
+func TestTx_Watch(t *testing.T) {
+	c := &Client{work: &work{}}
+	require.Nil(t, c.work.add(workTx))
+
+	rolledBack := make(chan struct{})
+	tx := &Tx{client: c}
+	tx.OnRollback(func() { close(rolledBack) })
+
+	// database/sql rolls the transaction back once its context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	tx.watch(ctx)
+	cancel()
+
+	<-rolledBack
+	outcome, ctxDone := tx.state()
+	require.Equal(t, TxRolledBack, outcome)
+	require.True(t, ctxDone)
+	require.Nil(t, c.work.wait(context.Background()))
+
+	// ending first stops watching
+	tx = &Tx{}
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	tx.watch(ctx)
+	tx.end(TxCommitted)
+
+	outcome, ctxDone = tx.state()
+	require.Equal(t, TxCommitted, outcome)
+	require.False(t, ctxDone)
+}
+
+func TestTx_CallbacksWithSavepoints(t *testing.T) {
+	c := NewClient(nil)
+
+	if err := c.Start(""); err != nil {
+		t.Fatalf("Failed to start %v", err)
+	}
+
+	defer func() {
+		_ = c.Close()
+	}()
+
+	ctx := context.Background()
+
+	var calls []string
+	callback := func(name string) func() {
+		return func() { calls = append(calls, name) }
+	}
+
+	err := c.RunInTransaction(ctx, func(ctx context.Context, tx *Tx) error {
+		tx.OnCommit(callback("outer"))
+
+		err := tx.RunInSavepoint(ctx, func(ctx context.Context, tx *Tx) error {
+			tx.OnCommit(callback("kept"))
+
+			_ = tx.RunInSavepoint(ctx, func(ctx context.Context, tx *Tx) error {
+				tx.OnCommit(callback("discarded"))
+				tx.OnRollback(callback("discarded rollback"))
+				return errors.New("some error")
+			})
+
+			return nil
+		})
+		require.Nil(t, err)
+
+		require.Nil(t, tx.Savepoint(ctx, "manual"))
+		tx.OnCommit(callback("manual"))
+		require.Nil(t, tx.RollbackTo(ctx, "manual"))
+		require.Nil(t, tx.Release(ctx, "manual"))
+
+		tx.OnCommit(callback("last"))
+		return nil
+	}, nil)
+	require.Nil(t, err)
+	require.Equal(t, []string{"outer", "kept", "last"}, calls)
+
+	calls = nil
+	err = c.RunInTransaction(ctx, func(ctx context.Context, tx *Tx) error {
+		tx.OnCommit(callback("commit"))
+		tx.OnRollback(callback("rollback"))
+		_, err := tx.Exec("select * from does_not_exist")
+		return err
+	}, nil)
+	require.NotNil(t, err)
+	require.Equal(t, []string{"rollback"}, calls)
+}